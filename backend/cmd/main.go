@@ -34,10 +34,20 @@ func main() {
 	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Category{},
+		&models.LoyaltyProgram{},
+		&models.TransferPartner{},
+		&models.Merchant{},
 		&models.CreditCard{},
+		&models.CardCapGroup{},
+		&models.WelcomeOffer{},
 		&models.CardBenefit{},
+		&models.BenefitTier{},
+		&models.UserCard{},
 		&models.UserSpending{},
+		&models.Transaction{},
+		&models.RecommendationRun{},
 		&models.Recommendation{},
+		&models.Notification{},
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
@@ -49,7 +59,7 @@ func main() {
 
 	// Initialize repositories, services, and controllers
 	repos := repository.NewRepositories(db)
-	services := service.NewServices(repos)
+	services := service.NewServices(repos, cfg)
 	v := validator.NewValidator()
 	controllers := controller.NewControllers(services, v)
 
@@ -85,11 +95,32 @@ func setupRoutes(controllers *controller.Controllers) *gin.Engine {
 		api.POST("/users", controllers.User.CreateUser)
 		api.GET("/users", controllers.User.ListUsers)
 		api.GET("/users/:id", controllers.User.GetUser)
+		api.PUT("/users/:id/profile", controllers.User.UpdateUserProfile)
+		api.PUT("/users/:id/preferences", controllers.User.UpdateUserPreferences)
+		api.GET("/users/:id/notifications", controllers.Notification.ListNotifications)
+		api.POST("/users/:id/notifications/:notificationId/read", controllers.Notification.MarkNotificationRead)
+		api.GET("/users/:id/cards", controllers.User.GetUserCards)
+		api.POST("/users/:id/cards", controllers.User.AddUserCard)
+		api.PUT("/users/:id/cards/:userCardId", controllers.User.UpdateUserCard)
+		api.DELETE("/users/:id/cards/:userCardId", controllers.User.DeleteUserCard)
+		api.GET("/users/:id/transactions", controllers.Transaction.ListTransactions)
+		api.POST("/users/:id/transactions", controllers.Transaction.CreateTransaction)
+		api.GET("/users/:id/transactions/:transactionId", controllers.Transaction.GetTransaction)
+		api.PUT("/users/:id/transactions/:transactionId", controllers.Transaction.UpdateTransaction)
+		api.DELETE("/users/:id/transactions/:transactionId", controllers.Transaction.DeleteTransaction)
+		api.POST("/users/:id/statements/preview", controllers.Statement.PreviewStatement)
+		api.POST("/users/:id/statements/import", controllers.Statement.ImportStatement)
 
 		// Category routes
 		api.POST("/categories", controllers.Category.CreateCategory)
 		api.GET("/categories", controllers.Category.ListCategories)
 
+		// Merchant routes
+		api.GET("/merchants", controllers.Merchant.ListMerchants)
+
+		// Statement import
+		api.GET("/statements/banks", controllers.Statement.ListStatementBanks)
+
 		// Credit card routes
 		api.GET("/cards", controllers.CreditCard.ListCreditCards)
 		api.GET("/cards/:id", controllers.CreditCard.GetCreditCard)
@@ -99,13 +130,42 @@ func setupRoutes(controllers *controller.Controllers) *gin.Engine {
 		api.GET("/spending/users/:userId", controllers.Spending.GetUserSpending)
 
 		// Recommendation routes
+		api.GET("/recommendations/strategies", controllers.Recommendation.ListStrategies)
 		api.POST("/recommendations/users/:userId/generate", controllers.Recommendation.GenerateRecommendations)
 		api.GET("/recommendations/users/:userId", controllers.Recommendation.GetRecommendations)
+		api.GET("/recommendations/users/:userId/categories/:categoryId", controllers.Recommendation.GetRecommendationsByCategory)
+		api.GET("/recommendations/users/:userId/wallet", controllers.Recommendation.OptimizeWallet)
+		api.GET("/recommendations/users/:userId/best-card", controllers.Recommendation.ChooseCard)
+		api.POST("/recommendations/users/:userId/simulate", controllers.Recommendation.SimulateRecommendations)
+		api.GET("/recommendations/users/:userId/runs", controllers.Recommendation.ListRecommendationRuns)
+		api.GET("/recommendations/users/:userId/runs/:runId", controllers.Recommendation.GetRecommendationRun)
+		api.GET("/recommendations/users/:userId/diff", controllers.Recommendation.DiffRecommendationRuns)
 
 		// Admin routes
 		admin := api.Group("/admin")
 		{
 			admin.POST("/scrape", controllers.Scraping.ScrapeCardData)
+
+			// Batch regeneration of stored recommendations
+			admin.POST("/recommendations/batch", controllers.Batch.StartBatchJob)
+			admin.GET("/recommendations/batch", controllers.Batch.ListBatchJobs)
+			admin.GET("/recommendations/batch/:id", controllers.Batch.GetBatchJob)
+			admin.POST("/recommendations/batch/:id/cancel", controllers.Batch.CancelBatchJob)
+
+			// Loyalty program valuations
+			admin.GET("/loyalty-programs", controllers.LoyaltyProgram.ListLoyaltyPrograms)
+			admin.POST("/loyalty-programs", controllers.LoyaltyProgram.CreateLoyaltyProgram)
+			admin.PUT("/loyalty-programs/:id", controllers.LoyaltyProgram.UpdateLoyaltyProgram)
+			admin.DELETE("/loyalty-programs/:id", controllers.LoyaltyProgram.DeleteLoyaltyProgram)
+			admin.GET("/transfer-partners", controllers.LoyaltyProgram.ListTransferPartners)
+			admin.POST("/transfer-partners", controllers.LoyaltyProgram.CreateTransferPartner)
+			admin.PUT("/transfer-partners/:id", controllers.LoyaltyProgram.UpdateTransferPartner)
+			admin.DELETE("/transfer-partners/:id", controllers.LoyaltyProgram.DeleteTransferPartner)
+
+			// Merchant catalog
+			admin.POST("/merchants", controllers.Merchant.CreateMerchant)
+			admin.PUT("/merchants/:id", controllers.Merchant.UpdateMerchant)
+			admin.DELETE("/merchants/:id", controllers.Merchant.DeleteMerchant)
 		}
 	}
 
@@ -120,14 +180,50 @@ func cleanAndSeedRealData(db *gorm.DB) {
 		log.Printf("Warning: Error cleaning recommendations: %v", err)
 	}
 
+	if err := db.Exec("DELETE FROM recommendation_runs").Error; err != nil {
+		log.Printf("Warning: Error cleaning recommendation_runs: %v", err)
+	}
+
+	if err := db.Exec("DELETE FROM notifications").Error; err != nil {
+		log.Printf("Warning: Error cleaning notifications: %v", err)
+	}
+
+	if err := db.Exec("DELETE FROM user_cards").Error; err != nil {
+		log.Printf("Warning: Error cleaning user_cards: %v", err)
+	}
+
+	if err := db.Exec("DELETE FROM transactions").Error; err != nil {
+		log.Printf("Warning: Error cleaning transactions: %v", err)
+	}
+
 	if err := db.Exec("DELETE FROM user_spendings").Error; err != nil {
 		log.Printf("Warning: Error cleaning user_spendings: %v", err)
 	}
 
+	if err := db.Exec("DELETE FROM benefit_tiers").Error; err != nil {
+		log.Printf("Warning: Error cleaning benefit_tiers: %v", err)
+	}
+
+	if err := db.Exec("DELETE FROM card_benefit_merchants").Error; err != nil {
+		log.Printf("Warning: Error cleaning card_benefit_merchants: %v", err)
+	}
+
 	if err := db.Exec("DELETE FROM card_benefits").Error; err != nil {
 		log.Printf("Warning: Error cleaning card_benefits: %v", err)
 	}
 
+	if err := db.Exec("DELETE FROM welcome_offers").Error; err != nil {
+		log.Printf("Warning: Error cleaning welcome_offers: %v", err)
+	}
+
+	if err := db.Exec("DELETE FROM card_cap_groups").Error; err != nil {
+		log.Printf("Warning: Error cleaning card_cap_groups: %v", err)
+	}
+
+	if err := db.Exec("DELETE FROM card_excluded_categories").Error; err != nil {
+		log.Printf("Warning: Error cleaning card_excluded_categories: %v", err)
+	}
+
 	if err := db.Exec("DELETE FROM credit_cards").Error; err != nil {
 		log.Printf("Warning: Error cleaning credit_cards: %v", err)
 	}
@@ -140,9 +236,15 @@ func cleanAndSeedRealData(db *gorm.DB) {
 	sequences := []string{
 		"credit_cards_id_seq",
 		"card_benefits_id_seq",
+		"benefit_tiers_id_seq",
+		"card_cap_groups_id_seq",
+		"welcome_offers_id_seq",
 		"users_id_seq",
+		"user_cards_id_seq",
 		"user_spendings_id_seq",
 		"recommendations_id_seq",
+		"recommendation_runs_id_seq",
+		"notifications_id_seq",
 	}
 
 	for _, seq := range sequences {
@@ -158,9 +260,16 @@ func cleanAndSeedRealData(db *gorm.DB) {
 
 	log.Println("Categories seeded")
 
-	// Use scraping service to populate real card data (no more curated data)
+	// Seed loyalty programs so scraped cards can be linked to them
+	seedLoyaltyPrograms(db)
+
+	// Seed common merchants so spending and benefits can target them
+	seedMerchants(db)
+
+	// Use scraping service to populate real card data (no more curated data).
+	// There are no users to notify yet.
 	repos := repository.NewRepositories(db)
-	scrapingService := service.NewScrapingService(repos)
+	scrapingService := service.NewScrapingService(repos, nil)
 
 	log.Println("Starting real data scraping from web sources only...")
 	if err := scrapingService.ScrapeCardData(); err != nil {
@@ -205,6 +314,127 @@ func seedCategories(db *gorm.DB) {
 	log.Println("Categories seeding completed")
 }
 
+func seedLoyaltyPrograms(db *gorm.DB) {
+	log.Println("Seeding loyalty programs...")
+
+	// Points are valued at their cash or voucher redemption value; their value
+	// as KrisFlyer miles comes from the transfer partners seeded below
+	programs := []models.LoyaltyProgram{
+		{Name: "DBS Points", Type: models.LoyaltyTypePoints, Issuer: "DBS", CentsPerUnit: 1.0, Description: "Cash rebate value; transfers to KrisFlyer at 1:2"},
+		{Name: "UNI$", Type: models.LoyaltyTypePoints, Issuer: "UOB", CentsPerUnit: 1.0, Description: "Voucher value; transfers to KrisFlyer at 1:2"},
+		{Name: "OCBC$", Type: models.LoyaltyTypePoints, Issuer: "OCBC", CentsPerUnit: 0.25, Description: "Cash rebate value; transfers to KrisFlyer at 1:0.4"},
+		{Name: "Citi ThankYou Points", Type: models.LoyaltyTypePoints, Issuer: "Citibank", CentsPerUnit: 0.25, Description: "Cash rebate value; transfers to KrisFlyer at 1:0.4"},
+		{Name: "HSBC Reward Points", Type: models.LoyaltyTypePoints, Issuer: "HSBC", CentsPerUnit: 0.25, Description: "Voucher value; transfers to KrisFlyer at 1:0.4"},
+		{Name: "KrisFlyer", Type: models.LoyaltyTypeMiles, Issuer: "Singapore Airlines", CentsPerUnit: 1.5, Description: "Singapore Airlines miles"},
+	}
+
+	for _, program := range programs {
+		var existingProgram models.LoyaltyProgram
+		result := db.Where("name = ?", program.Name).First(&existingProgram)
+		if result.Error != nil {
+			if err := db.Create(&program).Error; err != nil {
+				log.Printf("Failed to create loyalty program %s: %v", program.Name, err)
+			} else {
+				log.Printf("Created loyalty program: %s", program.Name)
+			}
+		}
+	}
+
+	seedTransferPartners(db)
+
+	log.Println("Loyalty programs seeding completed")
+}
+
+func seedTransferPartners(db *gorm.DB) {
+	var krisFlyer models.LoyaltyProgram
+	if err := db.Where("name = ?", "KrisFlyer").First(&krisFlyer).Error; err != nil {
+		log.Printf("Skipping transfer partners: KrisFlyer not found")
+		return
+	}
+
+	// Ratios are miles per point; blocks and fees as charged by each bank
+	partners := []struct {
+		program   string
+		ratio     float64
+		blockSize float64
+		fee       float64
+	}{
+		{"DBS Points", 2, 5000, 27.25},
+		{"UNI$", 2, 5000, 25},
+		{"OCBC$", 0.4, 25000, 25},
+		{"Citi ThankYou Points", 0.4, 25000, 27.25},
+		{"HSBC Reward Points", 0.4, 25000, 0},
+	}
+
+	for _, entry := range partners {
+		var program models.LoyaltyProgram
+		if err := db.Where("name = ?", entry.program).First(&program).Error; err != nil {
+			continue
+		}
+
+		var existingPartner models.TransferPartner
+		result := db.Where("from_program_id = ? AND to_program_id = ?", program.ID, krisFlyer.ID).First(&existingPartner)
+		if result.Error != nil {
+			partner := models.TransferPartner{
+				FromProgramID: program.ID,
+				ToProgramID:   krisFlyer.ID,
+				Ratio:         entry.ratio,
+				BlockSize:     entry.blockSize,
+				ConversionFee: entry.fee,
+			}
+			if err := db.Create(&partner).Error; err != nil {
+				log.Printf("Failed to create transfer partner %s -> KrisFlyer: %v", entry.program, err)
+			} else {
+				log.Printf("Created transfer partner: %s -> KrisFlyer", entry.program)
+			}
+		}
+	}
+}
+
+func seedMerchants(db *gorm.DB) {
+	log.Println("Seeding merchants...")
+
+	merchants := []struct {
+		name     string
+		category string
+	}{
+		{"Grab", "Transport"},
+		{"Gojek", "Transport"},
+		{"foodpanda", "Dining"},
+		{"Deliveroo", "Dining"},
+		{"Shopee", "Online"},
+		{"Lazada", "Online"},
+		{"Amazon", "Online"},
+		{"FairPrice", "Groceries"},
+		{"Cold Storage", "Groceries"},
+		{"Sheng Siong", "Groceries"},
+		{"Shell", "Petrol"},
+		{"Esso", "Petrol"},
+		{"Netflix", "Entertainment"},
+	}
+
+	for _, entry := range merchants {
+		var category models.Category
+		if err := db.Where("name = ?", entry.category).First(&category).Error; err != nil {
+			log.Printf("Skipping merchant %s: category %s not found", entry.name, entry.category)
+			continue
+		}
+
+		var existingMerchant models.Merchant
+		result := db.Where("name = ?", entry.name).First(&existingMerchant)
+		if result.Error != nil {
+			merchant := models.Merchant{Name: entry.name, CategoryID: category.ID}
+			if err := db.Create(&merchant).Error; err != nil {
+				log.Printf("Failed to create merchant %s: %v", entry.name, err)
+			} else {
+				log.Printf("Created merchant: %s", entry.name)
+			}
+		}
+	}
+
+	log.Println("Merchants seeding completed")
+}
+
 func createDemoUser(db *gorm.DB) {
 	log.Println("Creating demo user...")
 
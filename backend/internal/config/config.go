@@ -13,9 +13,11 @@ import (
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	JWT      JWTConfig
+	Database       DatabaseConfig
+	Server         ServerConfig
+	JWT            JWTConfig
+	Recommendation RecommendationConfig
+	Notification   NotificationConfig
 }
 
 type DatabaseConfig struct {
@@ -36,6 +38,26 @@ type JWTConfig struct {
 	Secret string
 }
 
+// RecommendationConfig sets how long recommendation runs are kept per user.
+// A zero value disables that limit.
+type RecommendationConfig struct {
+	RetainRuns int
+	RetainDays int
+}
+
+// NotificationConfig sets when users are told about better cards and how
+// email and webhook notifications are sent. Email is disabled without an
+// SMTP host.
+type NotificationConfig struct {
+	MinImprovement float64
+	SMTPHost       string
+	SMTPPort       string
+	SMTPUsername   string
+	SMTPPassword   string
+	SMTPFrom       string
+	WebhookTimeout int
+}
+
 func LoadConfig() *Config {
 	// Load .env file if it exists
 	if err := godotenv.Load(); err != nil {
@@ -58,6 +80,19 @@ func LoadConfig() *Config {
 		JWT: JWTConfig{
 			Secret: getEnv("JWT_SECRET", "your-secret-key"),
 		},
+		Recommendation: RecommendationConfig{
+			RetainRuns: getEnvAsInt("RECOMMENDATION_RETAIN_RUNS", 20),
+			RetainDays: getEnvAsInt("RECOMMENDATION_RETAIN_DAYS", 180),
+		},
+		Notification: NotificationConfig{
+			MinImprovement: getEnvAsFloat("NOTIFICATION_MIN_IMPROVEMENT", 50),
+			SMTPHost:       getEnv("SMTP_HOST", ""),
+			SMTPPort:       getEnv("SMTP_PORT", "587"),
+			SMTPUsername:   getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
+			SMTPFrom:       getEnv("SMTP_FROM", "notifications@gotocard.local"),
+			WebhookTimeout: getEnvAsInt("NOTIFICATION_WEBHOOK_TIMEOUT_SECONDS", 10),
+		},
 	}
 }
 
@@ -77,6 +112,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func (c *Config) GetDatabaseURL() string {
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		c.Database.Host,
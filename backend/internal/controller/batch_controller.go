@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/service"
+	"gotocard-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BatchController struct {
+	services  *service.Services
+	validator *validator.Validator
+}
+
+func NewBatchController(services *service.Services, validator *validator.Validator) *BatchController {
+	return &BatchController{
+		services:  services,
+		validator: validator,
+	}
+}
+
+func (c *BatchController) StartBatchJob(ctx *gin.Context) {
+	var req models.BatchJobRequest
+	// An empty body regenerates every user with the default options
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			return
+		}
+	}
+
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := c.services.Batch.StartRegeneration(req)
+	if errors.Is(err, service.ErrBatchJobRunning) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrUnknownStrategy) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "Batch job started",
+		"job":     job,
+	})
+}
+
+func (c *BatchController) ListBatchJobs(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"jobs": c.services.Batch.ListJobs()})
+}
+
+func (c *BatchController) GetBatchJob(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := c.services.Batch.GetJob(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+func (c *BatchController) CancelBatchJob(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := c.services.Batch.CancelJob(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Batch job cancellation requested",
+		"job":     job,
+	})
+}
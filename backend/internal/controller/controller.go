@@ -9,8 +9,14 @@ type Controllers struct {
 	User           *UserController
 	Category       *CategoryController
 	CreditCard     *CreditCardController
+	LoyaltyProgram *LoyaltyProgramController
+	Merchant       *MerchantController
 	Spending       *SpendingController
+	Transaction    *TransactionController
+	Statement      *StatementController
 	Recommendation *RecommendationController
+	Batch          *BatchController
+	Notification   *NotificationController
 	Scraping       *ScrapingController
 }
 
@@ -19,8 +25,14 @@ func NewControllers(services *service.Services, validator *validator.Validator)
 		User:           NewUserController(services, validator),
 		Category:       NewCategoryController(services, validator),
 		CreditCard:     NewCreditCardController(services, validator),
+		LoyaltyProgram: NewLoyaltyProgramController(services, validator),
+		Merchant:       NewMerchantController(services, validator),
 		Spending:       NewSpendingController(services, validator),
+		Transaction:    NewTransactionController(services, validator),
+		Statement:      NewStatementController(services, validator),
 		Recommendation: NewRecommendationController(services, validator),
+		Batch:          NewBatchController(services, validator),
+		Notification:   NewNotificationController(services, validator),
 		Scraping:       NewScrapingController(services, validator),
 	}
 } 
\ No newline at end of file
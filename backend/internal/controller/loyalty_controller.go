@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/service"
+	"gotocard-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoyaltyProgramController struct {
+	services  *service.Services
+	validator *validator.Validator
+}
+
+func NewLoyaltyProgramController(services *service.Services, validator *validator.Validator) *LoyaltyProgramController {
+	return &LoyaltyProgramController{
+		services:  services,
+		validator: validator,
+	}
+}
+
+func (c *LoyaltyProgramController) ListLoyaltyPrograms(ctx *gin.Context) {
+	programs, err := c.services.LoyaltyProgram.ListLoyaltyPrograms()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch loyalty programs"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"loyalty_programs": programs})
+}
+
+func (c *LoyaltyProgramController) CreateLoyaltyProgram(ctx *gin.Context) {
+	var program models.LoyaltyProgram
+	if err := ctx.ShouldBindJSON(&program); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := c.validator.Validate(&program); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := c.services.LoyaltyProgram.CreateLoyaltyProgram(&program)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":         "Loyalty program created successfully",
+		"loyalty_program": program,
+	})
+}
+
+func (c *LoyaltyProgramController) UpdateLoyaltyProgram(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loyalty program ID"})
+		return
+	}
+
+	program, err := c.services.LoyaltyProgram.GetLoyaltyProgramByID(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Loyalty program not found"})
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(program); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	program.ID = uint(id)
+
+	if err := c.validator.Validate(program); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = c.services.LoyaltyProgram.UpdateLoyaltyProgram(program)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":         "Loyalty program updated successfully",
+		"loyalty_program": program,
+	})
+}
+
+func (c *LoyaltyProgramController) DeleteLoyaltyProgram(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loyalty program ID"})
+		return
+	}
+
+	err = c.services.LoyaltyProgram.DeleteLoyaltyProgram(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Loyalty program deleted successfully"})
+}
+
+func (c *LoyaltyProgramController) ListTransferPartners(ctx *gin.Context) {
+	partners, err := c.services.LoyaltyProgram.ListTransferPartners()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfer partners"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"transfer_partners": partners})
+}
+
+func (c *LoyaltyProgramController) CreateTransferPartner(ctx *gin.Context) {
+	var partner models.TransferPartner
+	if err := ctx.ShouldBindJSON(&partner); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := c.validator.Validate(&partner); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := c.services.LoyaltyProgram.CreateTransferPartner(&partner)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":          "Transfer partner created successfully",
+		"transfer_partner": partner,
+	})
+}
+
+func (c *LoyaltyProgramController) UpdateTransferPartner(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer partner ID"})
+		return
+	}
+
+	partner, err := c.services.LoyaltyProgram.GetTransferPartnerByID(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Transfer partner not found"})
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(partner); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	partner.ID = uint(id)
+	// The preloaded program is stale if the target program was changed
+	partner.ToProgram = nil
+
+	if err := c.validator.Validate(partner); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = c.services.LoyaltyProgram.UpdateTransferPartner(partner)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":          "Transfer partner updated successfully",
+		"transfer_partner": partner,
+	})
+}
+
+func (c *LoyaltyProgramController) DeleteTransferPartner(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer partner ID"})
+		return
+	}
+
+	err = c.services.LoyaltyProgram.DeleteTransferPartner(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Transfer partner deleted successfully"})
+}
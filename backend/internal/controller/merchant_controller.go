@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/service"
+	"gotocard-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MerchantController struct {
+	services  *service.Services
+	validator *validator.Validator
+}
+
+func NewMerchantController(services *service.Services, validator *validator.Validator) *MerchantController {
+	return &MerchantController{
+		services:  services,
+		validator: validator,
+	}
+}
+
+func (c *MerchantController) ListMerchants(ctx *gin.Context) {
+	merchants, err := c.services.Merchant.ListMerchants()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch merchants"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"merchants": merchants})
+}
+
+func (c *MerchantController) CreateMerchant(ctx *gin.Context) {
+	var merchant models.Merchant
+	if err := ctx.ShouldBindJSON(&merchant); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := c.validator.Validate(&merchant); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := c.services.Merchant.CreateMerchant(&merchant)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":  "Merchant created successfully",
+		"merchant": merchant,
+	})
+}
+
+func (c *MerchantController) UpdateMerchant(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid merchant ID"})
+		return
+	}
+
+	merchant, err := c.services.Merchant.GetMerchantByID(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Merchant not found"})
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(merchant); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	merchant.ID = uint(id)
+	// The preloaded category is stale if the category was changed
+	merchant.Category = nil
+
+	if err := c.validator.Validate(merchant); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = c.services.Merchant.UpdateMerchant(merchant)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Merchant updated successfully",
+		"merchant": merchant,
+	})
+}
+
+func (c *MerchantController) DeleteMerchant(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid merchant ID"})
+		return
+	}
+
+	err = c.services.Merchant.DeleteMerchant(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Merchant deleted successfully"})
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/service"
+	"gotocard-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	services  *service.Services
+	validator *validator.Validator
+}
+
+func NewNotificationController(services *service.Services, validator *validator.Validator) *NotificationController {
+	return &NotificationController{
+		services:  services,
+		validator: validator,
+	}
+}
+
+func (c *NotificationController) ListNotifications(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var opts models.NotificationListOptions
+	if err := ctx.ShouldBindQuery(&opts); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	notifications, err := c.services.Notification.ListNotifications(uint(userID), opts)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"notifications": notifications})
+}
+
+func (c *NotificationController) MarkNotificationRead(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	notificationParam := ctx.Param("notificationId")
+	notificationID, err := strconv.ParseUint(notificationParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	notification, err := c.services.Notification.MarkNotificationRead(uint(userID), uint(notificationID))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":      "Notification marked as read",
+		"notification": notification,
+	})
+}
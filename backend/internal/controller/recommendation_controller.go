@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -31,7 +32,22 @@ func (c *RecommendationController) GenerateRecommendations(ctx *gin.Context) {
 		return
 	}
 
-	recommendations, err := c.services.Recommendation.GenerateRecommendations(uint(userID))
+	var opts models.RecommendationOptions
+	if err := ctx.ShouldBindQuery(&opts); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if err := c.validator.Validate(&opts); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recommendations, err := c.services.Recommendation.GenerateRecommendations(uint(userID), opts)
+	if errors.Is(err, service.ErrUnknownStrategy) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -43,6 +59,18 @@ func (c *RecommendationController) GenerateRecommendations(ctx *gin.Context) {
 	})
 }
 
+func (c *RecommendationController) ListStrategies(ctx *gin.Context) {
+	var strategies []gin.H
+	for _, strategy := range service.Strategies() {
+		strategies = append(strategies, gin.H{
+			"name":        strategy.Name(),
+			"description": strategy.Description(),
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"strategies": strategies})
+}
+
 func (c *RecommendationController) GetRecommendations(ctx *gin.Context) {
 	idParam := ctx.Param("userId")
 	userID, err := strconv.ParseUint(idParam, 10, 32)
@@ -60,6 +88,236 @@ func (c *RecommendationController) GetRecommendations(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"recommendations": recommendations})
 }
 
+func (c *RecommendationController) GetRecommendationsByCategory(ctx *gin.Context) {
+	idParam := ctx.Param("userId")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	categoryParam := ctx.Param("categoryId")
+	categoryID, err := strconv.ParseUint(categoryParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var page models.PageOptions
+	if err := ctx.ShouldBindQuery(&page); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if err := c.validator.Validate(&page); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.services.Recommendation.GetRecommendationsByCategory(uint(userID), uint(categoryID), page)
+	if errors.Is(err, service.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *RecommendationController) OptimizeWallet(ctx *gin.Context) {
+	idParam := ctx.Param("userId")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.WalletRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wallet, err := c.services.Recommendation.OptimizeWallet(uint(userID), req)
+	if errors.Is(err, service.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"wallet": wallet})
+}
+
+func (c *RecommendationController) ChooseCard(ctx *gin.Context) {
+	idParam := ctx.Param("userId")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.PurchaseRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	purchase, err := c.services.Recommendation.ChooseCard(uint(userID), req)
+	if errors.Is(err, service.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"purchase": purchase})
+}
+
+func (c *RecommendationController) SimulateRecommendations(ctx *gin.Context) {
+	idParam := ctx.Param("userId")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.SimulationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	simulation, err := c.services.Recommendation.SimulateRecommendations(uint(userID), &req)
+	if errors.Is(err, service.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrUnknownStrategy) || errors.Is(err, service.ErrNegativeSpending) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"simulation": simulation})
+}
+
+func (c *RecommendationController) ListRecommendationRuns(ctx *gin.Context) {
+	idParam := ctx.Param("userId")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var opts models.RunListOptions
+	if err := ctx.ShouldBindQuery(&opts); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if err := c.validator.Validate(&opts); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	runs, err := c.services.Recommendation.ListRecommendationRuns(uint(userID), opts)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+func (c *RecommendationController) GetRecommendationRun(ctx *gin.Context) {
+	idParam := ctx.Param("userId")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	runParam := ctx.Param("runId")
+	runID, err := strconv.ParseUint(runParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid run ID"})
+		return
+	}
+
+	run, err := c.services.Recommendation.GetRecommendationRun(uint(userID), uint(runID))
+	if errors.Is(err, service.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"run":             run.Run,
+		"recommendations": run.Recommendations,
+	})
+}
+
+func (c *RecommendationController) DiffRecommendationRuns(ctx *gin.Context) {
+	idParam := ctx.Param("userId")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.RunDiffRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	diff, err := c.services.Recommendation.DiffRecommendationRuns(uint(userID), req)
+	if errors.Is(err, service.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"diff": diff})
+}
+
 type SpendingController struct {
 	services  *service.Services
 	validator *validator.Validator
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/service"
+	"gotocard-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStatementSize caps uploaded statement files; a year of card
+// transactions is well under this
+const maxStatementSize = 5 << 20
+
+type StatementController struct {
+	services  *service.Services
+	validator *validator.Validator
+}
+
+func NewStatementController(services *service.Services, validator *validator.Validator) *StatementController {
+	return &StatementController{
+		services:  services,
+		validator: validator,
+	}
+}
+
+func (c *StatementController) ListStatementBanks(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"banks": c.services.Statement.ListStatementBanks()})
+}
+
+// PreviewStatement reads a multipart upload with the statement in "file",
+// the bank code in "bank" and optionally the held card in "card_id".
+func (c *StatementController) PreviewStatement(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	bank := ctx.PostForm("bank")
+	if bank == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Bank is required"})
+		return
+	}
+
+	var cardID *uint
+	if cardParam := ctx.PostForm("card_id"); cardParam != "" {
+		id, err := strconv.ParseUint(cardParam, 10, 32)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+			return
+		}
+		card := uint(id)
+		cardID = &card
+	}
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Statement file is required"})
+		return
+	}
+	if header.Size > maxStatementSize {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Statement file is too large"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read statement file"})
+		return
+	}
+	defer file.Close()
+
+	preview, err := c.services.Statement.PreviewStatement(uint(userID), bank, cardID, file)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"preview": preview})
+}
+
+func (c *StatementController) ImportStatement(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	userID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.StatementImportRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := c.validator.Validate(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.services.Statement.ImportStatement(uint(userID), &req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Statement imported successfully",
+		"import":  result,
+	})
+}
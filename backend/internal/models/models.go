@@ -1,17 +1,54 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null" validate:"required,email"`
-	Name      string         `json:"name" gorm:"not null" validate:"required,min=2,max=100"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	Email       string          `json:"email" gorm:"uniqueIndex;not null" validate:"required,email"`
+	Name        string          `json:"name" gorm:"not null" validate:"required,min=2,max=100"`
+	Profile     UserProfile     `json:"profile" gorm:"embedded"`
+	Preferences UserPreferences `json:"preferences" gorm:"embedded;embeddedPrefix:pref_"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt  `json:"-" gorm:"index"`
+}
+
+// Residency statuses used for card eligibility
+const (
+	ResidencyCitizen   = "citizen"
+	ResidencyPR        = "pr"
+	ResidencyForeigner = "foreigner"
+)
+
+// UserProfile is the eligibility information used to filter out cards a user
+// cannot apply for. Zero values mean unknown and are not checked.
+type UserProfile struct {
+	AnnualIncome    float64 `json:"annual_income" gorm:"default:0" validate:"min=0"`
+	Age             int     `json:"age" gorm:"default:0" validate:"omitempty,min=18,max=120"`
+	ResidencyStatus string  `json:"residency_status" validate:"omitempty,oneof=citizen pr foreigner"`
+}
+
+// UserPreferences are defaults applied to the user's recommendation requests.
+type UserPreferences struct {
+	Strategy      string                  `json:"strategy"`
+	Notifications NotificationPreferences `json:"notifications" gorm:"embedded;embeddedPrefix:notify_"`
+}
+
+// NotificationPreferences choose how a user hears about better cards. The
+// in-app inbox is always used unless Muted; email and the webhook are opt-in.
+// The webhook must be an https URL on a public address. A zero
+// MinImprovement uses the server's default threshold.
+type NotificationPreferences struct {
+	Muted          bool    `json:"muted"`
+	Email          bool    `json:"email"`
+	WebhookURL     string  `json:"webhook_url" validate:"omitempty,url,startswith=https://"`
+	MinImprovement float64 `json:"min_improvement" validate:"min=0"`
 }
 
 type Category struct {
@@ -24,90 +61,487 @@ type Category struct {
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// Merchant is a specific retailer or platform such as Grab or Shopee. Spend
+// at a merchant counts towards its category unless recorded under another.
+type Merchant struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"uniqueIndex;not null" validate:"required,min=2,max=100"`
+	CategoryID  uint           `json:"category_id" gorm:"not null;index" validate:"required"`
+	Description string         `json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Category *Category `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+}
+
 type CreditCard struct {
+	ID               uint            `json:"id" gorm:"primaryKey"`
+	Name             string          `json:"name" gorm:"not null" validate:"required,min=2,max=100"`
+	Bank             string          `json:"bank" gorm:"not null" validate:"required,min=2,max=50"`
+	CardType         string          `json:"card_type" gorm:"not null" validate:"required,oneof=visa mastercard amex"`
+	AnnualFee        float64         `json:"annual_fee" gorm:"default:0"`
+	ImageURL         string          `json:"image_url"`
+	Description      string          `json:"description"`
+	MinIncome        float64         `json:"min_income" gorm:"default:0"`
+	WelcomeBonus     string          `json:"welcome_bonus"`
+	LoyaltyProgramID *uint           `json:"loyalty_program_id,omitempty"`
+	SourceURL        string          `json:"source_url"`
+	IsActive         bool            `json:"is_active" gorm:"default:true"`
+	EffectiveFrom    *time.Time      `json:"effective_from,omitempty"`
+	EffectiveTo      *time.Time      `json:"effective_to,omitempty"`
+	Rules            CardRules       `json:"rules" gorm:"embedded;embeddedPrefix:rules_"`
+	Eligibility      CardEligibility `json:"eligibility" gorm:"embedded;embeddedPrefix:eligibility_"`
+	FeeWaiver        FeeWaiverRules  `json:"fee_waiver" gorm:"embedded;embeddedPrefix:fee_waiver_"`
+	BaseRate         BaseEarnRate    `json:"base_rate" gorm:"embedded;embeddedPrefix:base_"`
+	Overseas         OverseasTerms   `json:"overseas" gorm:"embedded;embeddedPrefix:overseas_"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt  `json:"-" gorm:"index"`
+
+	// Relationships
+	CardBenefits   []CardBenefit   `json:"card_benefits" gorm:"foreignKey:CardID"`
+	CapGroups      []CardCapGroup  `json:"cap_groups,omitempty" gorm:"foreignKey:CardID"`
+	LoyaltyProgram *LoyaltyProgram `json:"loyalty_program,omitempty" gorm:"foreignKey:LoyaltyProgramID"`
+	WelcomeOffers  []WelcomeOffer  `json:"welcome_offers,omitempty" gorm:"foreignKey:CardID"`
+	// Categories that earn nothing, not even the base rate
+	ExcludedCategories []Category `json:"excluded_categories,omitempty" gorm:"many2many:card_excluded_categories"`
+}
+
+// Welcome offer reward types. Cashback and gift amounts are in dollars;
+// points and miles are valued through the card's loyalty program.
+const (
+	WelcomeRewardCashback = "cashback"
+	WelcomeRewardGift     = "gift"
+	WelcomeRewardPoints   = "points"
+	WelcomeRewardMiles    = "miles"
+)
+
+// WelcomeOffer is a sign-up bonus earned by spending QualifyingSpend within
+// QualifyingMonths of approval. Nil validity dates are open-ended.
+type WelcomeOffer struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	CardID           uint           `json:"card_id" gorm:"not null;index"`
+	RewardType       string         `json:"reward_type" gorm:"not null" validate:"required,oneof=cashback gift points miles"`
+	RewardAmount     float64        `json:"reward_amount" gorm:"not null" validate:"required,gt=0"`
+	QualifyingSpend  float64        `json:"qualifying_spend" gorm:"default:0" validate:"min=0"`
+	QualifyingMonths int            `json:"qualifying_months" gorm:"default:0" validate:"min=0"`
+	NewToBankOnly    bool           `json:"new_to_bank_only" gorm:"default:false"`
+	ValidFrom        *time.Time     `json:"valid_from,omitempty"`
+	ValidUntil       *time.Time     `json:"valid_until,omitempty"`
+	Description      string         `json:"description"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// CardRules holds conditions evaluated against the user's whole monthly
+// spending on a card rather than per category.
+type CardRules struct {
+	MinTotalSpend float64 `json:"min_total_spend" gorm:"default:0"`
+}
+
+// BaseEarnRate is what a card earns on general spend in categories without a
+// specific benefit. Rates have the same units as a CardBenefit's.
+type BaseEarnRate struct {
+	CashbackRate float64 `json:"cashback_rate" gorm:"default:0" validate:"min=0,max=100"`
+	PointsRate   float64 `json:"points_rate" gorm:"default:0" validate:"min=0"`
+	MilesRate    float64 `json:"miles_rate" gorm:"default:0" validate:"min=0"`
+}
+
+// OverseasTerms cover spending charged in a foreign currency or by an
+// overseas merchant: the FX fee as a percent of the amount and the earn rate
+// such spend gets. Rates have the same units as a CardBenefit's; a card
+// without an overseas rate earns its usual benefits on overseas spend.
+type OverseasTerms struct {
+	FXFeeRate    float64 `json:"fx_fee_rate" gorm:"default:0" validate:"min=0,max=10"`
+	CashbackRate float64 `json:"cashback_rate" gorm:"default:0" validate:"min=0,max=100"`
+	PointsRate   float64 `json:"points_rate" gorm:"default:0" validate:"min=0"`
+	MilesRate    float64 `json:"miles_rate" gorm:"default:0" validate:"min=0"`
+}
+
+// FeeWaiverRules describe when a card's annual fee is not charged: for the
+// first FirstYearsWaived years, in any year the annual spend reaches
+// SpendThreshold, and otherwise with AutoWaiverProbability on request.
+type FeeWaiverRules struct {
+	FirstYearsWaived      int     `json:"first_years_waived" gorm:"default:0" validate:"min=0"`
+	SpendThreshold        float64 `json:"spend_threshold" gorm:"default:0" validate:"min=0"`
+	AutoWaiverProbability float64 `json:"auto_waiver_probability" gorm:"default:0" validate:"min=0,max=1"`
+}
+
+// CardEligibility holds application criteria beyond MinIncome, which applies
+// to citizens and PRs. Zero values mean no specific requirement.
+type CardEligibility struct {
+	MinIncomeForeigner float64 `json:"min_income_foreigner" gorm:"default:0"`
+	MinAge             int     `json:"min_age" gorm:"default:0"`
+	MaxAge             int     `json:"max_age" gorm:"default:0"`
+}
+
+// CardCapGroup is a monthly reward cap shared by every CardBenefit that
+// points at it through CapGroupID.
+type CardCapGroup struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CardID    uint           `json:"card_id" gorm:"not null;index"`
+	Name      string         `json:"name"`
+	RewardCap float64        `json:"reward_cap" gorm:"default:0"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// Loyalty program unit types
+const (
+	LoyaltyTypePoints = "points"
+	LoyaltyTypeMiles  = "miles"
+)
+
+// LoyaltyProgram is a points or miles currency with its dollar valuation,
+// e.g. DBS Points, UNI$ or KrisFlyer miles. Points that can be transferred
+// to an airline are worth the better of CentsPerUnit and their value as
+// miles after conversion fees.
+type LoyaltyProgram struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
-	Name         string         `json:"name" gorm:"not null" validate:"required,min=2,max=100"`
-	Bank         string         `json:"bank" gorm:"not null" validate:"required,min=2,max=50"`
-	CardType     string         `json:"card_type" gorm:"not null" validate:"required,oneof=visa mastercard amex"`
-	AnnualFee    float64        `json:"annual_fee" gorm:"default:0"`
-	ImageURL     string         `json:"image_url"`
+	Name         string         `json:"name" gorm:"uniqueIndex;not null" validate:"required,min=2,max=50"`
+	Type         string         `json:"type" gorm:"not null" validate:"required,oneof=points miles"`
+	Issuer       string         `json:"issuer"`
+	CentsPerUnit float64        `json:"cents_per_unit" gorm:"not null" validate:"required,gt=0"`
 	Description  string         `json:"description"`
-	MinIncome    float64        `json:"min_income" gorm:"default:0"`
-	WelcomeBonus string         `json:"welcome_bonus"`
-	SourceURL    string         `json:"source_url"`
-	IsActive     bool           `json:"is_active" gorm:"default:true"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
-	
+
 	// Relationships
-	CardBenefits []CardBenefit `json:"card_benefits" gorm:"foreignKey:CardID"`
+	TransferPartners []TransferPartner `json:"transfer_partners,omitempty" gorm:"foreignKey:FromProgramID"`
 }
 
-type CardBenefit struct {
+// TransferPartner converts a bank's points into an airline's miles. Points
+// move in whole blocks of BlockSize points (0 for any amount), each
+// converting to Ratio miles per point, and every conversion costs
+// ConversionFee dollars.
+type TransferPartner struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
-	CardID        uint           `json:"card_id" gorm:"not null"`
-	CategoryID    uint           `json:"category_id" gorm:"not null"`
-	CashbackRate  float64        `json:"cashback_rate" gorm:"default:0"`
-	PointsRate    float64        `json:"points_rate" gorm:"default:0"`
-	MilesRate     float64        `json:"miles_rate" gorm:"default:0"`
-	Cap           float64        `json:"cap" gorm:"default:0"`
-	MinSpend      float64        `json:"min_spend" gorm:"default:0"`
-	Description   string         `json:"description"`
+	FromProgramID uint           `json:"from_program_id" gorm:"not null;index" validate:"required"`
+	ToProgramID   uint           `json:"to_program_id" gorm:"not null" validate:"required"`
+	Ratio         float64        `json:"ratio" gorm:"not null" validate:"required,gt=0"`
+	BlockSize     float64        `json:"block_size" gorm:"default:0" validate:"min=0"`
+	ConversionFee float64        `json:"conversion_fee" gorm:"default:0" validate:"min=0"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
-	
+
 	// Relationships
-	Card     CreditCard `json:"card" gorm:"foreignKey:CardID"`
-	Category Category   `json:"category" gorm:"foreignKey:CategoryID"`
+	ToProgram *LoyaltyProgram `json:"to_program,omitempty" gorm:"foreignKey:ToProgramID"`
 }
 
-type UserSpending struct {
-	ID         uint           `json:"id" gorm:"primaryKey"`
-	UserID     uint           `json:"user_id" gorm:"not null"`
-	CategoryID uint           `json:"category_id" gorm:"not null"`
-	Amount     float64        `json:"amount" gorm:"not null" validate:"required,min=0"`
-	Month      int            `json:"month" gorm:"not null" validate:"required,min=1,max=12"`
-	Year       int            `json:"year" gorm:"not null" validate:"required,min=2020"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
-	
+// CardBenefit is what a card earns in one category. A benefit with Merchants
+// applies only to spend at those merchants, ahead of the category's general
+// benefit. Benefits with effective dates are promotions that apply only
+// within that window; nil dates are open-ended and EffectiveTo is the last
+// day the benefit applies. CashbackRate is a percentage of spend; PointsRate
+// and MilesRate are units earned per $100 of spend.
+type CardBenefit struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	CardID           uint           `json:"card_id" gorm:"not null"`
+	CategoryID       uint           `json:"category_id" gorm:"not null"`
+	CashbackRate     float64        `json:"cashback_rate" gorm:"default:0"`
+	PointsRate       float64        `json:"points_rate" gorm:"default:0"`
+	MilesRate        float64        `json:"miles_rate" gorm:"default:0"`
+	Cap              float64        `json:"cap" gorm:"default:0"`
+	MinSpend         float64        `json:"min_spend" gorm:"default:0"`
+	TierMode         string         `json:"tier_mode" gorm:"default:threshold" validate:"omitempty,oneof=threshold marginal"`
+	CapGroupID       *uint          `json:"cap_group_id,omitempty" gorm:"index"`
+	LoyaltyProgramID *uint          `json:"loyalty_program_id,omitempty"`
+	EffectiveFrom    *time.Time     `json:"effective_from,omitempty"`
+	EffectiveTo      *time.Time     `json:"effective_to,omitempty"`
+	Description      string         `json:"description"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
 	// Relationships
-	User     User     `json:"user" gorm:"foreignKey:UserID"`
-	Category Category `json:"category" gorm:"foreignKey:CategoryID"`
+	Card     CreditCard    `json:"card" gorm:"foreignKey:CardID"`
+	Category Category      `json:"category" gorm:"foreignKey:CategoryID"`
+	Tiers    []BenefitTier `json:"tiers,omitempty" gorm:"foreignKey:BenefitID"`
+	// Merchants limits the benefit to spend at these merchants
+	Merchants []Merchant `json:"merchants,omitempty" gorm:"many2many:card_benefit_merchants"`
+	// LoyaltyProgram overrides the card's program for this benefit's points or miles
+	LoyaltyProgram *LoyaltyProgram `json:"loyalty_program,omitempty" gorm:"foreignKey:LoyaltyProgramID"`
 }
 
-type Recommendation struct {
+// Tier modes for benefits with a tier schedule. In threshold mode the whole
+// monthly spend earns the rate of the highest tier reached; in marginal mode
+// each spend band earns its own tier's rate.
+const (
+	TierModeThreshold = "threshold"
+	TierModeMarginal  = "marginal"
+)
+
+// BenefitTier is one spend band of a benefit's reward schedule. The band
+// starts at MinSpend and runs up to the next tier's MinSpend; Cap limits the
+// spend that earns this tier's rate, as on CardBenefit.
+type BenefitTier struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
-	UserID       uint           `json:"user_id" gorm:"not null"`
-	CategoryID   uint           `json:"category_id" gorm:"not null"`
-	CardID       uint           `json:"card_id" gorm:"not null"`
-	Score        float64        `json:"score" gorm:"not null"`
-	EstimatedReward float64     `json:"estimated_reward"`
-	Reason       string         `json:"reason"`
+	BenefitID    uint           `json:"benefit_id" gorm:"not null;index"`
+	MinSpend     float64        `json:"min_spend" gorm:"default:0" validate:"min=0"`
+	CashbackRate float64        `json:"cashback_rate" gorm:"default:0"`
+	PointsRate   float64        `json:"points_rate" gorm:"default:0"`
+	MilesRate    float64        `json:"miles_rate" gorm:"default:0"`
+	Cap          float64        `json:"cap" gorm:"default:0"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// UserCard is a credit card the user already holds. Held cards form the
+// baseline that new card recommendations are measured against.
+type UserCard struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	UserID           uint           `json:"user_id" gorm:"not null;index"`
+	CardID           uint           `json:"card_id" gorm:"not null"`
+	Since            *time.Time     `json:"since"`
+	CreditLimit      float64        `json:"credit_limit" gorm:"default:0" validate:"min=0"`
+	AnniversaryMonth int            `json:"anniversary_month" gorm:"default:0" validate:"omitempty,min=1,max=12"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Card CreditCard `json:"card" gorm:"foreignKey:CardID"`
+}
+
+// UserSpending is spend recorded in one category in one month, optionally
+// with the held card it was charged to. Amount is in SGD; overseas spend
+// also records the currency it was charged in and the amount in that
+// currency. Derived records are monthly totals rebuilt from the user's
+// transactions whenever they change.
+type UserSpending struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	UserID         uint           `json:"user_id" gorm:"not null"`
+	CategoryID     uint           `json:"category_id" gorm:"not null"`
+	MerchantID     *uint          `json:"merchant_id,omitempty" gorm:"index"`
+	CardID         *uint          `json:"card_id,omitempty" gorm:"index"`
+	Amount         float64        `json:"amount" gorm:"not null" validate:"required,min=0"`
+	Overseas       bool           `json:"overseas" gorm:"default:false"`
+	Currency       string         `json:"currency" gorm:"size:3;default:SGD"`
+	OriginalAmount float64        `json:"original_amount,omitempty" gorm:"default:0"`
+	Month          int            `json:"month" gorm:"not null" validate:"required,min=1,max=12"`
+	Year           int            `json:"year" gorm:"not null" validate:"required,min=2020"`
+	Derived        bool           `json:"derived" gorm:"default:false"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 	
+	// Relationships
+	User     User      `json:"user" gorm:"foreignKey:UserID"`
+	Category Category  `json:"category" gorm:"foreignKey:CategoryID"`
+	Merchant *Merchant `json:"merchant,omitempty" gorm:"foreignKey:MerchantID"`
+}
+
+// Transaction channels: how the purchase was made.
+const (
+	ChannelInStore     = "in_store"
+	ChannelOnline      = "online"
+	ChannelContactless = "contactless"
+)
+
+// Transaction is a single purchase as it appears on a statement. Amount is
+// in SGD; overseas purchases also record the currency charged and the amount
+// in that currency. The user's monthly spending is derived from these.
+// Imported transactions keep a fingerprint of the statement line so
+// importing the same statement again does not record them twice.
+type Transaction struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	UserID         uint           `json:"user_id" gorm:"not null;index"`
+	Date           time.Time      `json:"date" gorm:"not null;index"`
+	Description    string         `json:"description" gorm:"not null"`
+	MerchantID     *uint          `json:"merchant_id,omitempty" gorm:"index"`
+	CategoryID     uint           `json:"category_id" gorm:"not null"`
+	CardID         *uint          `json:"card_id,omitempty" gorm:"index"`
+	Amount         float64        `json:"amount" gorm:"not null"`
+	Currency       string         `json:"currency" gorm:"size:3;default:SGD"`
+	OriginalAmount float64        `json:"original_amount,omitempty" gorm:"default:0"`
+	Overseas       bool           `json:"overseas" gorm:"default:false"`
+	Channel        string         `json:"channel" gorm:"default:in_store"`
+	Fingerprint    string         `json:"-" gorm:"size:64;index"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Category Category    `json:"category" gorm:"foreignKey:CategoryID"`
+	Merchant *Merchant   `json:"merchant,omitempty" gorm:"foreignKey:MerchantID"`
+	Card     *CreditCard `json:"card,omitempty" gorm:"foreignKey:CardID"`
+}
+
+type Recommendation struct {
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	UserID           uint           `json:"user_id" gorm:"not null"`
+	RunID            *uint          `json:"run_id" gorm:"index"`
+	CategoryID       uint           `json:"category_id" gorm:"not null"`
+	CardID           uint           `json:"card_id" gorm:"not null"`
+	Score            float64        `json:"score" gorm:"not null"`
+	EstimatedReward  float64        `json:"estimated_reward"`
+	Reason           string         `json:"reason"`
+	Breakdown        ScoreBreakdown `json:"breakdown" gorm:"type:jsonb"`
+	Strategy         string         `json:"strategy"`
+	Eligible         bool           `json:"eligible" gorm:"default:true"`
+	IneligibleReason string         `json:"ineligible_reason,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
 	// Relationships
 	User     User       `json:"user" gorm:"foreignKey:UserID"`
 	Category Category   `json:"category" gorm:"foreignKey:CategoryID"`
 	Card     CreditCard `json:"card" gorm:"foreignKey:CardID"`
 }
 
+// RecommendationRun is one generation of a user's recommendations. Runs are
+// kept so results can be compared over time; the fingerprints record what
+// went into the run so a change can be traced to spending, catalog or engine.
+type RecommendationRun struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	UserID         uint                  `json:"user_id" gorm:"not null;index"`
+	EngineVersion  string                `json:"engine_version" gorm:"not null"`
+	SpendingHash   string                `json:"spending_hash" gorm:"not null"`
+	CatalogVersion string                `json:"catalog_version" gorm:"not null"`
+	Options        RecommendationOptions `json:"options" gorm:"embedded;embeddedPrefix:option_"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt        `json:"-" gorm:"index"`
+
+	// Relationships
+	Recommendations []Recommendation `json:"recommendations,omitempty" gorm:"foreignKey:RunID"`
+}
+
+// ScoreComponent is one signed term of a recommendation's raw score.
+type ScoreComponent struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+}
+
+// ScoreBreakdown records every input to a recommendation's score so a
+// ranking can be explained and audited. Reward amounts are monthly unless
+// prefixed with Annual. ExpectedReward, RewardLow and RewardHigh are the
+// mean, 10th and 90th percentile of the reward over months simulated from
+// HistoryMonths of recorded spending; they are zero without history. Rewards
+// are net of the FXFees charged on OverseasSpend.
+type ScoreBreakdown struct {
+	MonthlySpend          float64          `json:"monthly_spend"`
+	GrossReward           float64          `json:"gross_reward"`
+	CappedAmount          float64          `json:"capped_amount"`
+	MonthlyReward         float64          `json:"monthly_reward"`
+	AnnualReward          float64          `json:"annual_reward"`
+	MinSpend              float64          `json:"min_spend"`
+	MinTotalSpend         float64          `json:"min_total_spend"`
+	MinSpendMet           bool             `json:"min_spend_met"`
+	AnnualFee             float64          `json:"annual_fee"`
+	FeeWaiver             float64          `json:"fee_waiver"`
+	NetBenefit            float64          `json:"net_benefit"`
+	WelcomeBonus          float64          `json:"welcome_bonus"`
+	WelcomeBonusQualified bool             `json:"welcome_bonus_qualified"`
+	WelcomeNewToBankOnly  bool             `json:"welcome_new_to_bank_only"`
+	FirstYearValue        float64          `json:"first_year_value"`
+	OngoingValue          float64          `json:"ongoing_value"`
+	AmortizedValue        float64          `json:"amortized_value"`
+	Horizon               string           `json:"horizon"`
+	RewardRate            float64          `json:"reward_rate"`
+	BaseRate              bool             `json:"base_rate"`
+	BaselineReward        float64          `json:"baseline_reward"`
+	IncrementalReward     float64          `json:"incremental_reward"`
+	MerchantReward        float64          `json:"merchant_reward"`
+	AnnualMiles           float64          `json:"annual_miles"`
+	MilesPerDollar        float64          `json:"miles_per_dollar"`
+	TransferPartner       string           `json:"transfer_partner,omitempty"`
+	ConversionFee         float64          `json:"conversion_fee"`
+	OverseasSpend         float64          `json:"overseas_spend"`
+	OverseasRate          bool             `json:"overseas_rate"`
+	FXFees                float64          `json:"fx_fees"`
+	ExpectedReward        float64          `json:"expected_reward"`
+	RewardLow             float64          `json:"reward_low"`
+	RewardHigh            float64          `json:"reward_high"`
+	MinSpendProbability   float64          `json:"min_spend_probability"`
+	HistoryMonths         int              `json:"history_months"`
+	Components            []ScoreComponent `json:"components"`
+	RawScore              float64          `json:"raw_score"`
+	FinalScore            float64          `json:"final_score"`
+}
+
+// Value stores the breakdown as JSON.
+func (b ScoreBreakdown) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Scan reads a breakdown stored as JSON; NULL leaves it empty.
+func (b *ScoreBreakdown) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*b = ScoreBreakdown{}
+		return nil
+	case []byte:
+		return json.Unmarshal(data, b)
+	case string:
+		return json.Unmarshal([]byte(data), b)
+	default:
+		return fmt.Errorf("unsupported score breakdown type %T", value)
+	}
+}
+
 // Request/Response DTOs
 type CreateUserRequest struct {
 	Name  string `json:"name" validate:"required,min=2,max=100"`
 	Email string `json:"email" validate:"required,email"`
 }
 
+type UpdateProfileRequest struct {
+	UserProfile
+}
+
+// UpdatePreferencesRequest changes only the preferences it includes, so the
+// strategy and the notification settings can be saved separately.
+type UpdatePreferencesRequest struct {
+	Strategy      *string                  `json:"strategy"`
+	Notifications *NotificationPreferences `json:"notifications"`
+}
+
+type UserCardRequest struct {
+	CardID           uint       `json:"card_id" validate:"required"`
+	Since            *time.Time `json:"since"`
+	CreditLimit      float64    `json:"credit_limit" validate:"min=0"`
+	AnniversaryMonth int        `json:"anniversary_month" validate:"omitempty,min=1,max=12"`
+}
+
+// SpendingRequest records spend in SGD in a category, optionally at a
+// merchant; without a category the merchant's own category is used. Spend in
+// another currency is overseas; set Overseas for SGD spend charged by an
+// overseas merchant. CardID must be one of the user's held cards.
 type SpendingRequest struct {
-	CategoryID uint    `json:"category_id" validate:"required"`
-	Amount     float64 `json:"amount" validate:"required,min=0"`
-	Month      int     `json:"month" validate:"required,min=1,max=12"`
-	Year       int     `json:"year" validate:"required,min=2020"`
+	CategoryID     uint    `json:"category_id" validate:"required_without=MerchantID"`
+	MerchantID     *uint   `json:"merchant_id"`
+	CardID         *uint   `json:"card_id"`
+	Amount         float64 `json:"amount" validate:"required,min=0"`
+	Overseas       bool    `json:"overseas"`
+	Currency       string  `json:"currency" validate:"omitempty,len=3,alpha"`
+	OriginalAmount float64 `json:"original_amount" validate:"min=0"`
+	Month          int     `json:"month" validate:"required,min=1,max=12"`
+	Year           int     `json:"year" validate:"required,min=2020"`
+}
+
+// TransactionRequest records or replaces one transaction. As with
+// SpendingRequest the category defaults to the merchant's, spend in another
+// currency is overseas and CardID must be one of the user's held cards.
+type TransactionRequest struct {
+	Date           time.Time `json:"date" validate:"required"`
+	Description    string    `json:"description" validate:"required,max=255"`
+	CategoryID     uint      `json:"category_id" validate:"required_without=MerchantID"`
+	MerchantID     *uint     `json:"merchant_id"`
+	CardID         *uint     `json:"card_id"`
+	Amount         float64   `json:"amount" validate:"required,gt=0"`
+	Currency       string    `json:"currency" validate:"omitempty,len=3,alpha"`
+	OriginalAmount float64   `json:"original_amount" validate:"min=0"`
+	Overseas       bool      `json:"overseas"`
+	Channel        string    `json:"channel" validate:"omitempty,oneof=in_store online contactless"`
 }
 
 type RecommendationRequest struct {
@@ -115,11 +549,377 @@ type RecommendationRequest struct {
 	CategoryID uint `json:"category_id,omitempty"`
 }
 
+// RecommendationOptions carries the per-request knobs for recommendation
+// generation. AsOf evaluates the card terms in effect on that date instead
+// of today's.
+type RecommendationOptions struct {
+	WindowMonths      int        `json:"window_months" form:"window_months" validate:"omitempty,min=1,max=36"`
+	IncludeIneligible bool       `json:"include_ineligible" form:"include_ineligible"`
+	Horizon           string     `json:"horizon" form:"horizon" validate:"omitempty,oneof=ongoing first_year amortized"`
+	PerCategory       int        `json:"per_category" form:"per_category" validate:"omitempty,min=1,max=20"`
+	Strategy          string     `json:"strategy" form:"strategy"`
+	AsOf              *Date      `json:"as_of,omitempty" form:"as_of" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// DateLayout is how a Date is written.
+const DateLayout = "2006-01-02"
+
+// Date is a calendar date written as "2006-01-02", in query strings and JSON
+// bodies alike. JSON bodies may also give an RFC 3339 timestamp, of which
+// only the date is kept. It is stored as a timestamp.
+type Date string
+
+// Time returns the start of the date in UTC.
+func (d Date) Time() (time.Time, error) {
+	return time.Parse(DateLayout, string(d))
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	date, err := time.Parse(DateLayout, text)
+	if err != nil {
+		date, err = time.Parse(time.RFC3339, text)
+		if err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", text)
+		}
+	}
+	*d = Date(date.Format(DateLayout))
+	return nil
+}
+
+// GormDataType keeps dates in timestamp columns.
+func (Date) GormDataType() string {
+	return "timestamptz"
+}
+
+// Value stores the date as the timestamp of its start.
+func (d Date) Value() (driver.Value, error) {
+	return d.Time()
+}
+
+// Scan reads a date stored as a timestamp; NULL leaves it empty.
+func (d *Date) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*d = ""
+		return nil
+	case time.Time:
+		*d = Date(data.Format(DateLayout))
+		return nil
+	default:
+		return fmt.Errorf("unsupported date type %T", value)
+	}
+}
+
+// Value horizons for ranking cards: the steady-state annual value, the first
+// year including the welcome bonus, or the bonus spread over several years.
+const (
+	HorizonOngoing   = "ongoing"
+	HorizonFirstYear = "first_year"
+	HorizonAmortized = "amortized"
+)
+
 type RecommendationResponse struct {
-	ID              uint    `json:"id"`
-	Card            CreditCard `json:"card"`
-	Category        Category `json:"category"`
-	Score           float64 `json:"score"`
-	EstimatedReward float64 `json:"estimated_reward"`
-	Reason          string  `json:"reason"`
-} 
\ No newline at end of file
+	ID               uint           `json:"id"`
+	Card             CreditCard     `json:"card"`
+	Category         Category       `json:"category"`
+	Score            float64        `json:"score"`
+	EstimatedReward  float64        `json:"estimated_reward"`
+	Reason           string         `json:"reason"`
+	Breakdown        ScoreBreakdown `json:"breakdown"`
+	Strategy         string         `json:"strategy"`
+	Eligible         bool           `json:"eligible"`
+	IneligibleReason string         `json:"ineligible_reason,omitempty"`
+}
+
+// PageOptions selects a page of a list; Page is 1-based.
+type PageOptions struct {
+	Page     int `form:"page" validate:"omitempty,min=1"`
+	PageSize int `form:"page_size" validate:"omitempty,min=1,max=50"`
+}
+
+type CategoryRecommendationsResponse struct {
+	Category        Category                 `json:"category"`
+	Recommendations []RecommendationResponse `json:"recommendations"`
+	Page            int                      `json:"page"`
+	PageSize        int                      `json:"page_size"`
+	Total           int64                    `json:"total"`
+}
+
+// RunListOptions pages through a user's recommendation runs, newest first.
+type RunListOptions struct {
+	Limit int `form:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// RunDiffRequest selects two runs to compare; To defaults to the latest run.
+type RunDiffRequest struct {
+	From uint `form:"from" validate:"required"`
+	To   uint `form:"to"`
+}
+
+type RecommendationRunResponse struct {
+	Run             RecommendationRun        `json:"run"`
+	Recommendations []RecommendationResponse `json:"recommendations"`
+}
+
+// ScoreChange is a (card, category) recommendation present in both runs of
+// a diff. Ranks are 1-based positions within each run.
+type ScoreChange struct {
+	CardID       uint    `json:"card_id"`
+	CardName     string  `json:"card_name"`
+	CategoryID   uint    `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	FromScore    float64 `json:"from_score"`
+	ToScore      float64 `json:"to_score"`
+	Delta        float64 `json:"delta"`
+	FromRank     int     `json:"from_rank"`
+	ToRank       int     `json:"to_rank"`
+}
+
+type RunDiff struct {
+	From            RecommendationRun        `json:"from"`
+	To              RecommendationRun        `json:"to"`
+	SpendingChanged bool                     `json:"spending_changed"`
+	CatalogChanged  bool                     `json:"catalog_changed"`
+	EngineChanged   bool                     `json:"engine_changed"`
+	Entered         []RecommendationResponse `json:"entered"`
+	Left            []RecommendationResponse `json:"left"`
+	ScoreChanges    []ScoreChange            `json:"score_changes"`
+}
+
+// Simulation modes: replace uses the given spending as the whole monthly
+// profile, delta adds it to the user's recorded monthly averages.
+const (
+	SimulationReplace = "replace"
+	SimulationDelta   = "delta"
+)
+
+type SimulatedSpending struct {
+	CategoryID    uint    `json:"category_id" validate:"required"`
+	MonthlyAmount float64 `json:"monthly_amount"`
+}
+
+// SimulationRequest describes a what-if spending profile to run through the
+// recommendation engine without saving anything.
+type SimulationRequest struct {
+	RecommendationOptions
+	Mode     string              `json:"mode" validate:"omitempty,oneof=replace delta"`
+	Spending []SimulatedSpending `json:"spending" validate:"required,min=1,dive"`
+}
+
+type SimulationResponse struct {
+	Spending  []SimulatedSpending      `json:"spending"`
+	Simulated []RecommendationResponse `json:"simulated"`
+	Current   []RecommendationResponse `json:"current"`
+}
+
+// WalletRequest configures the multi-card wallet optimizer.
+type WalletRequest struct {
+	RecommendationOptions
+	MaxCards int `json:"max_cards" form:"max_cards" validate:"omitempty,min=1,max=5"`
+}
+
+// WalletAssignment records which wallet card a spending category is charged to.
+type WalletAssignment struct {
+	Category     Category `json:"category"`
+	CardID       uint     `json:"card_id"`
+	CardName     string   `json:"card_name"`
+	MonthlySpend float64  `json:"monthly_spend"`
+	AnnualReward float64  `json:"annual_reward"`
+}
+
+type WalletResponse struct {
+	Cards             []CreditCard       `json:"cards"`
+	Assignments       []WalletAssignment `json:"assignments"`
+	TotalAnnualReward float64            `json:"total_annual_reward"`
+	TotalAnnualFee    float64            `json:"total_annual_fee"`
+	WelcomeBonus      float64            `json:"welcome_bonus"`
+	NetBenefit        float64            `json:"net_benefit"`
+}
+
+// Batch job statuses
+const (
+	BatchJobRunning   = "running"
+	BatchJobCompleted = "completed"
+	BatchJobCancelled = "cancelled"
+)
+
+// BatchJobRequest starts regenerating stored recommendations for many users.
+// Without UserIDs every user is included; StaleOnly further skips users whose
+// latest run already used the current card catalog and engine. Options apply
+// to every user, and an empty strategy uses each user's preferred one.
+type BatchJobRequest struct {
+	UserIDs     []uint                `json:"user_ids"`
+	StaleOnly   bool                  `json:"stale_only"`
+	Concurrency int                   `json:"concurrency" validate:"omitempty,min=1,max=16"`
+	Options     RecommendationOptions `json:"options"`
+}
+
+// BatchJobError records why regenerating one user's recommendations failed.
+type BatchJobError struct {
+	UserID uint   `json:"user_id"`
+	Error  string `json:"error"`
+}
+
+// BatchJob reports the progress of a batch regeneration.
+type BatchJob struct {
+	ID         uint            `json:"id"`
+	Status     string          `json:"status"`
+	Total      int             `json:"total"`
+	Processed  int             `json:"processed"`
+	Succeeded  int             `json:"succeeded"`
+	Failed     int             `json:"failed"`
+	Skipped    int             `json:"skipped"`
+	Errors     []BatchJobError `json:"errors"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Notification tells a user that a new or changed card beats their stored
+// recommendations. Improvement is the annual net benefit gained over the
+// best stored card for the category. CardVersion fingerprints the card's
+// terms when the user was told, so a later change to them is told again.
+type Notification struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	UserID      uint           `json:"user_id" gorm:"not null;index"`
+	CardID      uint           `json:"card_id" gorm:"not null"`
+	CardVersion string         `json:"-" gorm:"size:16"`
+	CategoryID  uint           `json:"category_id" gorm:"not null"`
+	Title       string         `json:"title" gorm:"not null"`
+	Message     string         `json:"message"`
+	Improvement float64        `json:"improvement"`
+	ReadAt      *time.Time     `json:"read_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Card     CreditCard `json:"card" gorm:"foreignKey:CardID"`
+	Category Category   `json:"category" gorm:"foreignKey:CategoryID"`
+}
+
+type NotificationListOptions struct {
+	Unread bool `form:"unread"`
+}
+
+// PurchaseRequest describes a purchase to pick one of the user's held cards
+// for. Amount is in SGD, the category defaults to the merchant's and the
+// date defaults to today.
+type PurchaseRequest struct {
+	MerchantID *uint      `json:"merchant_id" form:"merchant_id"`
+	CategoryID uint       `json:"category_id" form:"category_id" validate:"required_without=MerchantID"`
+	Amount     float64    `json:"amount" form:"amount" validate:"required,gt=0"`
+	Overseas   bool       `json:"overseas" form:"overseas"`
+	Date       *time.Time `json:"date" form:"date" time_format:"2006-01-02"`
+}
+
+// CardChoice is what charging a purchase to one held card earns, given the
+// spend already charged to the card this month. BenefitSpend is that spend
+// under the benefit the purchase earns, before the purchase; CapRemaining is
+// the spend left under the benefit's cap after it, and is omitted without a
+// cap.
+type CardChoice struct {
+	Card           CreditCard `json:"card"`
+	MarginalReward float64    `json:"marginal_reward"`
+	EffectiveRate  float64    `json:"effective_rate"`
+	BenefitSpend   float64    `json:"benefit_spend"`
+	Cap            float64    `json:"cap"`
+	CapRemaining   *float64   `json:"cap_remaining,omitempty"`
+	MinSpend       float64    `json:"min_spend"`
+	MinSpendMet    bool       `json:"min_spend_met"`
+	FXFee          float64    `json:"fx_fee"`
+	Reason         string     `json:"reason"`
+}
+
+// PurchaseResponse ranks the user's held cards by the reward a purchase adds.
+type PurchaseResponse struct {
+	Category Category     `json:"category"`
+	Merchant *Merchant    `json:"merchant,omitempty"`
+	Amount   float64      `json:"amount"`
+	Date     time.Time    `json:"date"`
+	Cards    []CardChoice `json:"cards"`
+}
+
+// TransactionListOptions filters and pages a user's transactions. From and
+// To are inclusive dates.
+type TransactionListOptions struct {
+	PageOptions
+	From       *time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To         *time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+	CategoryID uint       `form:"category_id"`
+	MerchantID uint       `form:"merchant_id"`
+	CardID     uint       `form:"card_id"`
+	Channel    string     `form:"channel" validate:"omitempty,oneof=in_store online contactless"`
+}
+
+type TransactionListResponse struct {
+	Transactions []Transaction `json:"transactions"`
+	Page         int           `json:"page"`
+	PageSize     int           `json:"page_size"`
+	Total        int64         `json:"total"`
+}
+
+// StatementBank is a bank whose CSV statement exports can be imported.
+type StatementBank struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// StatementLine is one charge read from a statement, with the merchant and
+// category it was matched to. Amount is in SGD. Fingerprint identifies the
+// line across imports and Duplicate marks lines already in the ledger.
+type StatementLine struct {
+	Line           int       `json:"line"`
+	Date           time.Time `json:"date" validate:"required"`
+	Description    string    `json:"description" validate:"required,max=255"`
+	Amount         float64   `json:"amount" validate:"gt=0"`
+	Currency       string    `json:"currency" validate:"omitempty,len=3,alpha"`
+	OriginalAmount float64   `json:"original_amount" validate:"min=0"`
+	Overseas       bool      `json:"overseas"`
+	MerchantID     *uint     `json:"merchant_id"`
+	CategoryID     uint      `json:"category_id"`
+	Channel        string    `json:"channel" validate:"omitempty,oneof=in_store online contactless"`
+	Fingerprint    string    `json:"fingerprint"`
+	Duplicate      bool      `json:"duplicate"`
+}
+
+// SkippedStatementLine is a statement row that is not a purchase, such as a
+// payment or refund.
+type SkippedStatementLine struct {
+	Line        int     `json:"line"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	Reason      string  `json:"reason"`
+}
+
+// StatementPreview is a parsed statement before anything is saved. Lines
+// without a category must be given one before importing.
+type StatementPreview struct {
+	Bank          string                 `json:"bank"`
+	CardID        *uint                  `json:"card_id,omitempty"`
+	Lines         []StatementLine        `json:"lines"`
+	Skipped       []SkippedStatementLine `json:"skipped"`
+	Total         float64                `json:"total"`
+	Duplicates    int                    `json:"duplicates"`
+	Uncategorized int                    `json:"uncategorized"`
+}
+
+// StatementImportRequest commits previewed lines, possibly with corrected
+// categories, merchants or overseas flags, to the user's ledger. CardID must
+// be one of the user's held cards.
+type StatementImportRequest struct {
+	Bank   string          `json:"bank" validate:"required"`
+	CardID *uint           `json:"card_id"`
+	Lines  []StatementLine `json:"lines" validate:"required,min=1,dive"`
+}
+
+// StatementImportResult reports the transactions recorded by an import and
+// how many lines were already in the ledger.
+type StatementImportResult struct {
+	Imported     int           `json:"imported"`
+	Duplicates   int           `json:"duplicates"`
+	Transactions []Transaction `json:"transactions"`
+}
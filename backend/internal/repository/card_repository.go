@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"gotocard-backend/internal/models"
 	"gorm.io/gorm"
 )
@@ -13,13 +15,38 @@ func NewCreditCardRepository(db *gorm.DB) CreditCardRepository {
 	return &creditCardRepository{db: db}
 }
 
+// orderTiers preloads benefit tiers from the lowest spend band upwards.
+func orderTiers(db *gorm.DB) *gorm.DB {
+	return db.Order("min_spend ASC")
+}
+
+// effectiveAt keeps the rows whose effective window covers asOf. A missing
+// bound is open-ended and the effective_to date is inclusive.
+func effectiveAt(asOf time.Time) func(db *gorm.DB) *gorm.DB {
+	day := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location())
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(effective_from IS NULL OR effective_from <= ?) AND (effective_to IS NULL OR effective_to >= ?)", asOf, day)
+	}
+}
+
+// preloadCardTerms loads everything the engine needs to value a card, under
+// the given association prefix. With an asOf date only the benefits in
+// effect on that date are loaded.
+func preloadCardTerms(db *gorm.DB, prefix string, asOf *time.Time) *gorm.DB {
+	var benefitConditions []interface{}
+	if asOf != nil {
+		benefitConditions = append(benefitConditions, effectiveAt(*asOf))
+	}
+	return db.Preload(prefix+"CardBenefits", benefitConditions...).Preload(prefix+"CardBenefits.Category").Preload(prefix+"CardBenefits.Tiers", orderTiers).Preload(prefix+"CardBenefits.LoyaltyProgram.TransferPartners.ToProgram").Preload(prefix+"CardBenefits.Merchants").Preload(prefix+"CapGroups").Preload(prefix+"LoyaltyProgram.TransferPartners.ToProgram").Preload(prefix+"WelcomeOffers").Preload(prefix+"ExcludedCategories")
+}
+
 func (r *creditCardRepository) Create(card *models.CreditCard) error {
 	return r.db.Create(card).Error
 }
 
 func (r *creditCardRepository) GetByID(id uint) (*models.CreditCard, error) {
 	var card models.CreditCard
-	err := r.db.Preload("CardBenefits").Preload("CardBenefits.Category").First(&card, id).Error
+	err := preloadCardTerms(r.db, "", nil).First(&card, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -45,13 +72,20 @@ func (r *creditCardRepository) Delete(id uint) error {
 
 func (r *creditCardRepository) List() ([]models.CreditCard, error) {
 	var cards []models.CreditCard
-	err := r.db.Preload("CardBenefits").Preload("CardBenefits.Category").Find(&cards).Error
+	err := preloadCardTerms(r.db, "", nil).Find(&cards).Error
 	return cards, err
 }
 
+// GetActiveCards returns the active cards with the terms in effect today.
 func (r *creditCardRepository) GetActiveCards() ([]models.CreditCard, error) {
+	return r.GetActiveCardsAsOf(time.Now())
+}
+
+// GetActiveCardsAsOf returns the active cards offered on the given date,
+// each with only the benefits in effect on that date.
+func (r *creditCardRepository) GetActiveCardsAsOf(asOf time.Time) ([]models.CreditCard, error) {
 	var cards []models.CreditCard
-	err := r.db.Where("is_active = ?", true).Preload("CardBenefits").Preload("CardBenefits.Category").Find(&cards).Error
+	err := preloadCardTerms(r.db.Where("is_active = ?", true).Scopes(effectiveAt(asOf)), "", &asOf).Find(&cards).Error
 	return cards, err
 }
 
@@ -99,6 +133,138 @@ func (r *categoryRepository) List() ([]models.Category, error) {
 	return categories, err
 }
 
+type merchantRepository struct {
+	db *gorm.DB
+}
+
+func NewMerchantRepository(db *gorm.DB) MerchantRepository {
+	return &merchantRepository{db: db}
+}
+
+func (r *merchantRepository) Create(merchant *models.Merchant) error {
+	return r.db.Create(merchant).Error
+}
+
+func (r *merchantRepository) GetByID(id uint) (*models.Merchant, error) {
+	var merchant models.Merchant
+	err := r.db.Preload("Category").First(&merchant, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+func (r *merchantRepository) GetByName(name string) (*models.Merchant, error) {
+	var merchant models.Merchant
+	err := r.db.Where("name = ?", name).First(&merchant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+func (r *merchantRepository) Update(merchant *models.Merchant) error {
+	return r.db.Omit("Category").Save(merchant).Error
+}
+
+func (r *merchantRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Merchant{}, id).Error
+}
+
+func (r *merchantRepository) List() ([]models.Merchant, error) {
+	var merchants []models.Merchant
+	err := r.db.Preload("Category").Order("name ASC").Find(&merchants).Error
+	return merchants, err
+}
+
+type loyaltyProgramRepository struct {
+	db *gorm.DB
+}
+
+func NewLoyaltyProgramRepository(db *gorm.DB) LoyaltyProgramRepository {
+	return &loyaltyProgramRepository{db: db}
+}
+
+func (r *loyaltyProgramRepository) Create(program *models.LoyaltyProgram) error {
+	return r.db.Create(program).Error
+}
+
+func (r *loyaltyProgramRepository) GetByID(id uint) (*models.LoyaltyProgram, error) {
+	var program models.LoyaltyProgram
+	err := r.db.Preload("TransferPartners.ToProgram").First(&program, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
+
+func (r *loyaltyProgramRepository) GetByName(name string) (*models.LoyaltyProgram, error) {
+	var program models.LoyaltyProgram
+	err := r.db.Where("name = ?", name).First(&program).Error
+	if err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
+
+func (r *loyaltyProgramRepository) Update(program *models.LoyaltyProgram) error {
+	return r.db.Omit("TransferPartners").Save(program).Error
+}
+
+func (r *loyaltyProgramRepository) Delete(id uint) error {
+	return r.db.Delete(&models.LoyaltyProgram{}, id).Error
+}
+
+func (r *loyaltyProgramRepository) List() ([]models.LoyaltyProgram, error) {
+	var programs []models.LoyaltyProgram
+	err := r.db.Preload("TransferPartners.ToProgram").Order("name ASC").Find(&programs).Error
+	return programs, err
+}
+
+type transferPartnerRepository struct {
+	db *gorm.DB
+}
+
+func NewTransferPartnerRepository(db *gorm.DB) TransferPartnerRepository {
+	return &transferPartnerRepository{db: db}
+}
+
+func (r *transferPartnerRepository) Create(partner *models.TransferPartner) error {
+	return r.db.Create(partner).Error
+}
+
+func (r *transferPartnerRepository) GetByID(id uint) (*models.TransferPartner, error) {
+	var partner models.TransferPartner
+	err := r.db.Preload("ToProgram").First(&partner, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &partner, nil
+}
+
+func (r *transferPartnerRepository) GetByPrograms(fromProgramID, toProgramID uint) (*models.TransferPartner, error) {
+	var partner models.TransferPartner
+	err := r.db.Where("from_program_id = ? AND to_program_id = ?", fromProgramID, toProgramID).First(&partner).Error
+	if err != nil {
+		return nil, err
+	}
+	return &partner, nil
+}
+
+func (r *transferPartnerRepository) Update(partner *models.TransferPartner) error {
+	return r.db.Omit("ToProgram").Save(partner).Error
+}
+
+func (r *transferPartnerRepository) Delete(id uint) error {
+	return r.db.Delete(&models.TransferPartner{}, id).Error
+}
+
+func (r *transferPartnerRepository) List() ([]models.TransferPartner, error) {
+	var partners []models.TransferPartner
+	err := r.db.Preload("ToProgram").Order("from_program_id ASC, to_program_id ASC").Find(&partners).Error
+	return partners, err
+}
+
 type cardBenefitRepository struct {
 	db *gorm.DB
 }
@@ -113,7 +279,7 @@ func (r *cardBenefitRepository) Create(benefit *models.CardBenefit) error {
 
 func (r *cardBenefitRepository) GetByID(id uint) (*models.CardBenefit, error) {
 	var benefit models.CardBenefit
-	err := r.db.Preload("Card").Preload("Category").First(&benefit, id).Error
+	err := r.db.Preload("Card").Preload("Category").Preload("Tiers", orderTiers).Preload("Merchants").First(&benefit, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -122,13 +288,20 @@ func (r *cardBenefitRepository) GetByID(id uint) (*models.CardBenefit, error) {
 
 func (r *cardBenefitRepository) GetByCardID(cardID uint) ([]models.CardBenefit, error) {
 	var benefits []models.CardBenefit
-	err := r.db.Where("card_id = ?", cardID).Preload("Category").Find(&benefits).Error
+	err := r.db.Where("card_id = ?", cardID).Preload("Category").Preload("Tiers", orderTiers).Preload("Merchants").Find(&benefits).Error
+	return benefits, err
+}
+
+// GetByCardIDAsOf returns the card's benefits in effect on the given date.
+func (r *cardBenefitRepository) GetByCardIDAsOf(cardID uint, asOf time.Time) ([]models.CardBenefit, error) {
+	var benefits []models.CardBenefit
+	err := r.db.Where("card_id = ?", cardID).Scopes(effectiveAt(asOf)).Preload("Category").Preload("Tiers", orderTiers).Preload("Merchants").Find(&benefits).Error
 	return benefits, err
 }
 
 func (r *cardBenefitRepository) GetByCardAndCategory(cardID, categoryID uint) (*models.CardBenefit, error) {
 	var benefit models.CardBenefit
-	err := r.db.Where("card_id = ? AND category_id = ?", cardID, categoryID).First(&benefit).Error
+	err := r.db.Where("card_id = ? AND category_id = ?", cardID, categoryID).Preload("Tiers", orderTiers).First(&benefit).Error
 	if err != nil {
 		return nil, err
 	}
@@ -145,10 +318,62 @@ func (r *cardBenefitRepository) Delete(id uint) error {
 
 func (r *cardBenefitRepository) List() ([]models.CardBenefit, error) {
 	var benefits []models.CardBenefit
-	err := r.db.Preload("Card").Preload("Category").Find(&benefits).Error
+	err := r.db.Preload("Card").Preload("Category").Preload("Tiers", orderTiers).Preload("Merchants").Find(&benefits).Error
 	return benefits, err
 }
 
+type benefitTierRepository struct {
+	db *gorm.DB
+}
+
+func NewBenefitTierRepository(db *gorm.DB) BenefitTierRepository {
+	return &benefitTierRepository{db: db}
+}
+
+func (r *benefitTierRepository) Create(tier *models.BenefitTier) error {
+	return r.db.Create(tier).Error
+}
+
+func (r *benefitTierRepository) GetByID(id uint) (*models.BenefitTier, error) {
+	var tier models.BenefitTier
+	err := r.db.First(&tier, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tier, nil
+}
+
+func (r *benefitTierRepository) GetByBenefitID(benefitID uint) ([]models.BenefitTier, error) {
+	var tiers []models.BenefitTier
+	err := r.db.Where("benefit_id = ?", benefitID).Order("min_spend ASC").Find(&tiers).Error
+	return tiers, err
+}
+
+// ReplaceForBenefit swaps a benefit's whole tier schedule in one transaction.
+func (r *benefitTierRepository) ReplaceForBenefit(benefitID uint, tiers []models.BenefitTier) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("benefit_id = ?", benefitID).Delete(&models.BenefitTier{}).Error; err != nil {
+			return err
+		}
+		for i := range tiers {
+			tiers[i].ID = 0
+			tiers[i].BenefitID = benefitID
+			if err := tx.Create(&tiers[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *benefitTierRepository) Update(tier *models.BenefitTier) error {
+	return r.db.Save(tier).Error
+}
+
+func (r *benefitTierRepository) Delete(id uint) error {
+	return r.db.Delete(&models.BenefitTier{}, id).Error
+}
+
 type userSpendingRepository struct {
 	db *gorm.DB
 }
@@ -163,7 +388,7 @@ func (r *userSpendingRepository) Create(spending *models.UserSpending) error {
 
 func (r *userSpendingRepository) GetByID(id uint) (*models.UserSpending, error) {
 	var spending models.UserSpending
-	err := r.db.Preload("User").Preload("Category").First(&spending, id).Error
+	err := r.db.Preload("User").Preload("Category").Preload("Merchant").First(&spending, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -172,22 +397,45 @@ func (r *userSpendingRepository) GetByID(id uint) (*models.UserSpending, error)
 
 func (r *userSpendingRepository) GetByUserID(userID uint) ([]models.UserSpending, error) {
 	var spendings []models.UserSpending
-	err := r.db.Where("user_id = ?", userID).Preload("Category").Find(&spendings).Error
+	err := r.db.Where("user_id = ?", userID).Preload("Category").Preload("Merchant").Find(&spendings).Error
 	return spendings, err
 }
 
 func (r *userSpendingRepository) GetByUserAndCategory(userID, categoryID uint) ([]models.UserSpending, error) {
 	var spendings []models.UserSpending
-	err := r.db.Where("user_id = ? AND category_id = ?", userID, categoryID).Preload("Category").Find(&spendings).Error
+	err := r.db.Where("user_id = ? AND category_id = ?", userID, categoryID).Preload("Category").Preload("Merchant").Find(&spendings).Error
 	return spendings, err
 }
 
 func (r *userSpendingRepository) GetByUserAndMonth(userID uint, month, year int) ([]models.UserSpending, error) {
 	var spendings []models.UserSpending
-	err := r.db.Where("user_id = ? AND month = ? AND year = ?", userID, month, year).Preload("Category").Find(&spendings).Error
+	err := r.db.Where("user_id = ? AND month = ? AND year = ?", userID, month, year).Preload("Category").Preload("Merchant").Find(&spendings).Error
 	return spendings, err
 }
 
+// ReplaceDerived swaps the user's derived spending for a month in one
+// transaction. Derived records can always be rebuilt, so the old ones are
+// removed outright rather than soft deleted.
+func (r *userSpendingRepository) ReplaceDerived(userID uint, month, year int, spendings []models.UserSpending) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("user_id = ? AND month = ? AND year = ? AND derived = ?", userID, month, year, true).Delete(&models.UserSpending{}).Error
+		if err != nil {
+			return err
+		}
+		for i := range spendings {
+			spendings[i].ID = 0
+			spendings[i].UserID = userID
+			spendings[i].Month = month
+			spendings[i].Year = year
+			spendings[i].Derived = true
+			if err := tx.Omit("User", "Category", "Merchant").Create(&spendings[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (r *userSpendingRepository) Update(spending *models.UserSpending) error {
 	return r.db.Save(spending).Error
 }
@@ -217,16 +465,33 @@ func (r *recommendationRepository) GetByID(id uint) (*models.Recommendation, err
 	return &recommendation, nil
 }
 
+// orderRecommendations keeps a run's recommendations in ranked order
+const orderRecommendations = "eligible DESC, score DESC"
+
+// latestRun selects the ID of the user's most recent recommendation run.
+func (r *recommendationRepository) latestRun(userID uint) *gorm.DB {
+	return r.db.Model(&models.RecommendationRun{}).Select("MAX(id)").Where("user_id = ?", userID)
+}
+
 func (r *recommendationRepository) GetByUserID(userID uint) ([]models.Recommendation, error) {
 	var recommendations []models.Recommendation
-	err := r.db.Where("user_id = ?", userID).Preload("Category").Preload("Card").Find(&recommendations).Error
+	err := r.db.Where("user_id = ? AND run_id = (?)", userID, r.latestRun(userID)).Order(orderRecommendations).Preload("Category").Preload("Card").Find(&recommendations).Error
 	return recommendations, err
 }
 
-func (r *recommendationRepository) GetByUserAndCategory(userID, categoryID uint) ([]models.Recommendation, error) {
+// GetByUserAndCategory returns a page of the category's recommendations in
+// the user's latest run along with the total number available.
+func (r *recommendationRepository) GetByUserAndCategory(userID, categoryID uint, offset, limit int) ([]models.Recommendation, int64, error) {
+	query := r.db.Model(&models.Recommendation{}).Where("user_id = ? AND category_id = ? AND run_id = (?)", userID, categoryID, r.latestRun(userID))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var recommendations []models.Recommendation
-	err := r.db.Where("user_id = ? AND category_id = ?", userID, categoryID).Preload("Category").Preload("Card").Find(&recommendations).Error
-	return recommendations, err
+	err := query.Order(orderRecommendations).Offset(offset).Limit(limit).Preload("Category").Preload("Card").Find(&recommendations).Error
+	return recommendations, total, err
 }
 
 func (r *recommendationRepository) Update(recommendation *models.Recommendation) error {
@@ -239,4 +504,77 @@ func (r *recommendationRepository) Delete(id uint) error {
 
 func (r *recommendationRepository) DeleteByUserID(userID uint) error {
 	return r.db.Where("user_id = ?", userID).Delete(&models.Recommendation{}).Error
-} 
\ No newline at end of file
+}
+
+type recommendationRunRepository struct {
+	db *gorm.DB
+}
+
+func NewRecommendationRunRepository(db *gorm.DB) RecommendationRunRepository {
+	return &recommendationRunRepository{db: db}
+}
+
+// Create saves the run together with its recommendations.
+func (r *recommendationRunRepository) Create(run *models.RecommendationRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *recommendationRunRepository) GetByID(id uint) (*models.RecommendationRun, error) {
+	var run models.RecommendationRun
+	err := r.db.Preload("Recommendations", func(db *gorm.DB) *gorm.DB {
+		return db.Order(orderRecommendations)
+	}).Preload("Recommendations.Category").Preload("Recommendations.Card").First(&run, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *recommendationRunRepository) GetLatestByUserID(userID uint) (*models.RecommendationRun, error) {
+	var run models.RecommendationRun
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *recommendationRunRepository) ListByUserID(userID uint, limit int) ([]models.RecommendationRun, error) {
+	var runs []models.RecommendationRun
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// Prune permanently deletes the user's runs beyond the newest keep runs or
+// created before the given time, along with their recommendations. The latest
+// run is always kept. A keep of 0 or a zero time disables that rule.
+func (r *recommendationRunRepository) Prune(userID uint, keep int, before time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Model(&models.RecommendationRun{}).Where("user_id = ?", userID).Order("id DESC").Pluck("id", &ids).Error
+		if err != nil || len(ids) <= 1 {
+			return err
+		}
+
+		var expired []uint
+		if keep > 0 && len(ids) > keep {
+			expired = append(expired, ids[keep:]...)
+		}
+		if !before.IsZero() {
+			var old []uint
+			err := tx.Model(&models.RecommendationRun{}).Where("user_id = ? AND id <> ? AND created_at < ?", userID, ids[0], before).Pluck("id", &old).Error
+			if err != nil {
+				return err
+			}
+			expired = append(expired, old...)
+		}
+		if len(expired) == 0 {
+			return nil
+		}
+
+		if err := tx.Unscoped().Where("run_id IN ?", expired).Delete(&models.Recommendation{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", expired).Delete(&models.RecommendationRun{}).Error
+	})
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"gotocard-backend/internal/models"
 	"gorm.io/gorm"
 )
@@ -14,6 +16,16 @@ type UserRepository interface {
 	List() ([]models.User, error)
 }
 
+type UserCardRepository interface {
+	Create(userCard *models.UserCard) error
+	GetByID(id uint) (*models.UserCard, error)
+	GetByUserID(userID uint) ([]models.UserCard, error)
+	GetByUserIDAsOf(userID uint, asOf time.Time) ([]models.UserCard, error)
+	GetByUserAndCard(userID, cardID uint) (*models.UserCard, error)
+	Update(userCard *models.UserCard) error
+	Delete(id uint) error
+}
+
 type CategoryRepository interface {
 	Create(category *models.Category) error
 	GetByID(id uint) (*models.Category, error)
@@ -23,6 +35,15 @@ type CategoryRepository interface {
 	List() ([]models.Category, error)
 }
 
+type MerchantRepository interface {
+	Create(merchant *models.Merchant) error
+	GetByID(id uint) (*models.Merchant, error)
+	GetByName(name string) (*models.Merchant, error)
+	Update(merchant *models.Merchant) error
+	Delete(id uint) error
+	List() ([]models.Merchant, error)
+}
+
 type CreditCardRepository interface {
 	Create(card *models.CreditCard) error
 	GetByID(id uint) (*models.CreditCard, error)
@@ -31,54 +52,138 @@ type CreditCardRepository interface {
 	Delete(id uint) error
 	List() ([]models.CreditCard, error)
 	GetActiveCards() ([]models.CreditCard, error)
+	GetActiveCardsAsOf(asOf time.Time) ([]models.CreditCard, error)
+}
+
+type LoyaltyProgramRepository interface {
+	Create(program *models.LoyaltyProgram) error
+	GetByID(id uint) (*models.LoyaltyProgram, error)
+	GetByName(name string) (*models.LoyaltyProgram, error)
+	Update(program *models.LoyaltyProgram) error
+	Delete(id uint) error
+	List() ([]models.LoyaltyProgram, error)
+}
+
+type TransferPartnerRepository interface {
+	Create(partner *models.TransferPartner) error
+	GetByID(id uint) (*models.TransferPartner, error)
+	GetByPrograms(fromProgramID, toProgramID uint) (*models.TransferPartner, error)
+	Update(partner *models.TransferPartner) error
+	Delete(id uint) error
+	List() ([]models.TransferPartner, error)
 }
 
 type CardBenefitRepository interface {
 	Create(benefit *models.CardBenefit) error
 	GetByID(id uint) (*models.CardBenefit, error)
 	GetByCardID(cardID uint) ([]models.CardBenefit, error)
+	GetByCardIDAsOf(cardID uint, asOf time.Time) ([]models.CardBenefit, error)
 	GetByCardAndCategory(cardID, categoryID uint) (*models.CardBenefit, error)
 	Update(benefit *models.CardBenefit) error
 	Delete(id uint) error
 	List() ([]models.CardBenefit, error)
 }
 
+type BenefitTierRepository interface {
+	Create(tier *models.BenefitTier) error
+	GetByID(id uint) (*models.BenefitTier, error)
+	GetByBenefitID(benefitID uint) ([]models.BenefitTier, error)
+	ReplaceForBenefit(benefitID uint, tiers []models.BenefitTier) error
+	Update(tier *models.BenefitTier) error
+	Delete(id uint) error
+}
+
 type UserSpendingRepository interface {
 	Create(spending *models.UserSpending) error
 	GetByID(id uint) (*models.UserSpending, error)
 	GetByUserID(userID uint) ([]models.UserSpending, error)
 	GetByUserAndCategory(userID, categoryID uint) ([]models.UserSpending, error)
 	GetByUserAndMonth(userID uint, month, year int) ([]models.UserSpending, error)
+	ReplaceDerived(userID uint, month, year int, spendings []models.UserSpending) error
 	Update(spending *models.UserSpending) error
 	Delete(id uint) error
 }
 
+type TransactionRepository interface {
+	Create(transaction *models.Transaction) error
+	GetByID(id uint) (*models.Transaction, error)
+	List(userID uint, opts models.TransactionListOptions, offset, limit int) ([]models.Transaction, int64, error)
+	GetByUserAndMonth(userID uint, month, year int) ([]models.Transaction, error)
+	CreateBatch(transactions []models.Transaction) error
+	ExistingFingerprints(userID uint, fingerprints []string) (map[string]bool, error)
+	Update(transaction *models.Transaction) error
+	Delete(id uint) error
+}
+
 type RecommendationRepository interface {
 	Create(recommendation *models.Recommendation) error
 	GetByID(id uint) (*models.Recommendation, error)
 	GetByUserID(userID uint) ([]models.Recommendation, error)
-	GetByUserAndCategory(userID, categoryID uint) ([]models.Recommendation, error)
+	GetByUserAndCategory(userID, categoryID uint, offset, limit int) ([]models.Recommendation, int64, error)
 	Update(recommendation *models.Recommendation) error
 	Delete(id uint) error
 	DeleteByUserID(userID uint) error
 }
 
+type RecommendationRunRepository interface {
+	Create(run *models.RecommendationRun) error
+	GetByID(id uint) (*models.RecommendationRun, error)
+	GetLatestByUserID(userID uint) (*models.RecommendationRun, error)
+	ListByUserID(userID uint, limit int) ([]models.RecommendationRun, error)
+	Prune(userID uint, keep int, before time.Time) error
+}
+
+type NotificationRepository interface {
+	Create(notification *models.Notification) error
+	GetByID(id uint) (*models.Notification, error)
+	ListByUserID(userID uint, unreadOnly bool) ([]models.Notification, error)
+	ExistsForUserAndCard(userID, cardID uint, cardVersion string) (bool, error)
+	Update(notification *models.Notification) error
+}
+
 type Repositories struct {
-	User           UserRepository
-	Category       CategoryRepository
-	CreditCard     CreditCardRepository
-	CardBenefit    CardBenefitRepository
-	UserSpending   UserSpendingRepository
-	Recommendation RecommendationRepository
+	User              UserRepository
+	UserCard          UserCardRepository
+	Category          CategoryRepository
+	Merchant          MerchantRepository
+	CreditCard        CreditCardRepository
+	LoyaltyProgram    LoyaltyProgramRepository
+	TransferPartner   TransferPartnerRepository
+	CardBenefit       CardBenefitRepository
+	BenefitTier       BenefitTierRepository
+	UserSpending      UserSpendingRepository
+	Transaction       TransactionRepository
+	Recommendation    RecommendationRepository
+	RecommendationRun RecommendationRunRepository
+	Notification      NotificationRepository
+
+	db *gorm.DB
 }
 
 func NewRepositories(db *gorm.DB) *Repositories {
 	return &Repositories{
-		User:           NewUserRepository(db),
-		Category:       NewCategoryRepository(db),
-		CreditCard:     NewCreditCardRepository(db),
-		CardBenefit:    NewCardBenefitRepository(db),
-		UserSpending:   NewUserSpendingRepository(db),
-		Recommendation: NewRecommendationRepository(db),
+		User:              NewUserRepository(db),
+		UserCard:          NewUserCardRepository(db),
+		Category:          NewCategoryRepository(db),
+		Merchant:          NewMerchantRepository(db),
+		CreditCard:        NewCreditCardRepository(db),
+		LoyaltyProgram:    NewLoyaltyProgramRepository(db),
+		TransferPartner:   NewTransferPartnerRepository(db),
+		CardBenefit:       NewCardBenefitRepository(db),
+		BenefitTier:       NewBenefitTierRepository(db),
+		UserSpending:      NewUserSpendingRepository(db),
+		Transaction:       NewTransactionRepository(db),
+		Recommendation:    NewRecommendationRepository(db),
+		RecommendationRun: NewRecommendationRunRepository(db),
+		Notification:      NewNotificationRepository(db),
+		db:                db,
 	}
+}
+
+// InTransaction runs fn with repositories bound to one database transaction,
+// committing if fn returns nil and rolling back otherwise.
+func (r *Repositories) InTransaction(fn func(tx *Repositories) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepositories(tx))
+	})
 } 
\ No newline at end of file
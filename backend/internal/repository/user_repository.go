@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"gotocard-backend/internal/models"
 	"gorm.io/gorm"
 )
@@ -47,4 +49,210 @@ func (r *userRepository) List() ([]models.User, error) {
 	var users []models.User
 	err := r.db.Find(&users).Error
 	return users, err
-} 
\ No newline at end of file
+}
+
+type userCardRepository struct {
+	db *gorm.DB
+}
+
+func NewUserCardRepository(db *gorm.DB) UserCardRepository {
+	return &userCardRepository{db: db}
+}
+
+// preloadHeldCard loads each held card with everything the engine needs to
+// value it, limited to the benefits in effect at asOf when given.
+func preloadHeldCard(db *gorm.DB, asOf *time.Time) *gorm.DB {
+	return preloadCardTerms(db.Preload("Card"), "Card.", asOf)
+}
+
+func (r *userCardRepository) Create(userCard *models.UserCard) error {
+	return r.db.Create(userCard).Error
+}
+
+func (r *userCardRepository) GetByID(id uint) (*models.UserCard, error) {
+	var userCard models.UserCard
+	err := preloadHeldCard(r.db, nil).First(&userCard, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &userCard, nil
+}
+
+func (r *userCardRepository) GetByUserID(userID uint) ([]models.UserCard, error) {
+	var userCards []models.UserCard
+	err := preloadHeldCard(r.db.Where("user_id = ?", userID), nil).Order("id ASC").Find(&userCards).Error
+	return userCards, err
+}
+
+// GetByUserIDAsOf returns the user's cards with the benefits in effect on
+// the given date. Held cards are kept even if no longer offered.
+func (r *userCardRepository) GetByUserIDAsOf(userID uint, asOf time.Time) ([]models.UserCard, error) {
+	var userCards []models.UserCard
+	err := preloadHeldCard(r.db.Where("user_id = ?", userID), &asOf).Order("id ASC").Find(&userCards).Error
+	return userCards, err
+}
+
+func (r *userCardRepository) GetByUserAndCard(userID, cardID uint) (*models.UserCard, error) {
+	var userCard models.UserCard
+	err := r.db.Where("user_id = ? AND card_id = ?", userID, cardID).First(&userCard).Error
+	if err != nil {
+		return nil, err
+	}
+	return &userCard, nil
+}
+
+func (r *userCardRepository) Update(userCard *models.UserCard) error {
+	return r.db.Omit("Card").Save(userCard).Error
+}
+
+func (r *userCardRepository) Delete(id uint) error {
+	return r.db.Delete(&models.UserCard{}, id).Error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Create(notification *models.Notification) error {
+	return r.db.Omit("Card", "Category").Create(notification).Error
+}
+
+func (r *notificationRepository) GetByID(id uint) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.db.Preload("Card").Preload("Category").First(&notification, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// ListByUserID returns the user's notifications, newest first.
+func (r *notificationRepository) ListByUserID(userID uint, unreadOnly bool) ([]models.Notification, error) {
+	query := r.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	err := query.Order("id DESC").Preload("Card").Preload("Category").Find(&notifications).Error
+	return notifications, err
+}
+
+// ExistsForUserAndCard reports whether the user was already notified about
+// this version of the card's terms, so the same terms are not announced
+// twice.
+func (r *notificationRepository) ExistsForUserAndCard(userID, cardID uint, cardVersion string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).Where("user_id = ? AND card_id = ? AND card_version = ?", userID, cardID, cardVersion).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *notificationRepository) Update(notification *models.Notification) error {
+	return r.db.Omit("Card", "Category").Save(notification).Error
+}
+
+type transactionRepository struct {
+	db *gorm.DB
+}
+
+func NewTransactionRepository(db *gorm.DB) TransactionRepository {
+	return &transactionRepository{db: db}
+}
+
+func (r *transactionRepository) Create(transaction *models.Transaction) error {
+	return r.db.Omit("Category", "Merchant", "Card").Create(transaction).Error
+}
+
+func (r *transactionRepository) GetByID(id uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := r.db.Preload("Category").Preload("Merchant").Preload("Card").First(&transaction, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// List returns a page of the user's transactions matching the filters,
+// newest first, with the total number that match.
+func (r *transactionRepository) List(userID uint, opts models.TransactionListOptions, offset, limit int) ([]models.Transaction, int64, error) {
+	query := r.db.Model(&models.Transaction{}).Where("user_id = ?", userID)
+	if opts.From != nil {
+		query = query.Where("date >= ?", *opts.From)
+	}
+	if opts.To != nil {
+		query = query.Where("date < ?", opts.To.AddDate(0, 0, 1))
+	}
+	if opts.CategoryID != 0 {
+		query = query.Where("category_id = ?", opts.CategoryID)
+	}
+	if opts.MerchantID != 0 {
+		query = query.Where("merchant_id = ?", opts.MerchantID)
+	}
+	if opts.CardID != 0 {
+		query = query.Where("card_id = ?", opts.CardID)
+	}
+	if opts.Channel != "" {
+		query = query.Where("channel = ?", opts.Channel)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var transactions []models.Transaction
+	err := query.Order("date DESC, id DESC").Offset(offset).Limit(limit).
+		Preload("Category").Preload("Merchant").Preload("Card").Find(&transactions).Error
+	return transactions, total, err
+}
+
+func (r *transactionRepository) GetByUserAndMonth(userID uint, month, year int) ([]models.Transaction, error) {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	var transactions []models.Transaction
+	err := r.db.Where("user_id = ? AND date >= ? AND date < ?", userID, start, start.AddDate(0, 1, 0)).Find(&transactions).Error
+	return transactions, err
+}
+
+// CreateBatch records several transactions in one database transaction, so
+// either all of them are saved or none are.
+func (r *transactionRepository) CreateBatch(transactions []models.Transaction) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range transactions {
+			if err := tx.Omit("Category", "Merchant", "Card").Create(&transactions[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ExistingFingerprints reports which of the fingerprints the user's ledger
+// already holds.
+func (r *transactionRepository) ExistingFingerprints(userID uint, fingerprints []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(fingerprints) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	err := r.db.Model(&models.Transaction{}).Where("user_id = ? AND fingerprint IN ?", userID, fingerprints).Pluck("fingerprint", &found).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, fingerprint := range found {
+		existing[fingerprint] = true
+	}
+	return existing, nil
+}
+
+func (r *transactionRepository) Update(transaction *models.Transaction) error {
+	return r.db.Omit("Category", "Merchant", "Card").Save(transaction).Error
+}
+
+func (r *transactionRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Transaction{}, id).Error
+}
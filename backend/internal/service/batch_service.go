@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/repository"
+)
+
+const (
+	// defaultBatchConcurrency is how many users a batch job regenerates at once
+	defaultBatchConcurrency = 4
+	// maxBatchErrors caps the per-user errors kept on a job
+	maxBatchErrors = 100
+	// retainBatchJobs is how many finished jobs are kept for monitoring
+	retainBatchJobs = 20
+)
+
+// ErrBatchJobRunning is returned when a batch job is started while another
+// one is still running.
+var ErrBatchJobRunning = errors.New("a batch job is already running")
+
+// batchJob is a running or finished batch regeneration. Its status is
+// guarded by the service mutex.
+type batchJob struct {
+	status models.BatchJob
+	cancel context.CancelFunc
+}
+
+// batchService regenerates stored recommendations for many users in the
+// background. Jobs are kept in memory and do not survive a restart.
+type batchService struct {
+	repos           *repository.Repositories
+	recommendations RecommendationService
+
+	mu     sync.Mutex
+	jobs   map[uint]*batchJob
+	nextID uint
+}
+
+func NewBatchService(repos *repository.Repositories, recommendations RecommendationService) BatchService {
+	return &batchService{
+		repos:           repos,
+		recommendations: recommendations,
+		jobs:            make(map[uint]*batchJob),
+	}
+}
+
+// StartRegeneration selects the users to regenerate and starts a job over
+// them. Only one job runs at a time. The users are selected before the
+// service is locked, so job status requests are not held up by the database.
+func (s *batchService) StartRegeneration(req models.BatchJobRequest) (*models.BatchJob, error) {
+	if req.Options.Strategy != "" {
+		if _, err := LookupStrategy(req.Options.Strategy); err != nil {
+			return nil, err
+		}
+	}
+
+	userIDs := req.UserIDs
+	if len(userIDs) == 0 {
+		users, err := s.repos.User.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+		for _, user := range users {
+			userIDs = append(userIDs, user.ID)
+		}
+	}
+
+	// Runs made against a different catalog or engine are stale
+	var currentCatalog string
+	if req.StaleOnly {
+		cards, err := s.repos.CreditCard.GetActiveCardsAsOf(evaluationDate(req.Options))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get active cards: %w", err)
+		}
+		currentCatalog = catalogVersion(cards)
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.status.Status == models.BatchJobRunning {
+			return nil, ErrBatchJobRunning
+		}
+	}
+
+	s.nextID++
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &batchJob{
+		status: models.BatchJob{
+			ID:        s.nextID,
+			Status:    models.BatchJobRunning,
+			Total:     len(userIDs),
+			Errors:    []models.BatchJobError{},
+			StartedAt: time.Now(),
+		},
+		cancel: cancel,
+	}
+	s.jobs[job.status.ID] = job
+	s.pruneJobs()
+
+	go s.run(ctx, job, userIDs, concurrency, currentCatalog, req.Options)
+
+	status := job.snapshot()
+	return &status, nil
+}
+
+// run regenerates each user with a bounded pool of workers. Cancelling stops
+// handing out users; regenerations already underway are allowed to finish.
+func (s *batchService) run(ctx context.Context, job *batchJob, userIDs []uint, concurrency int, currentCatalog string, opts models.RecommendationOptions) {
+	queue := make(chan uint)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for userID := range queue {
+				s.regenerate(job, userID, currentCatalog, opts)
+			}
+		}()
+	}
+
+dispatch:
+	for _, userID := range userIDs {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case queue <- userID:
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	finished := time.Now()
+	job.status.FinishedAt = &finished
+	if ctx.Err() != nil {
+		job.status.Status = models.BatchJobCancelled
+	} else {
+		job.status.Status = models.BatchJobCompleted
+	}
+	job.cancel()
+	log.Printf("Batch job %d %s: %d succeeded, %d failed, %d skipped of %d users",
+		job.status.ID, job.status.Status, job.status.Succeeded, job.status.Failed, job.status.Skipped, job.status.Total)
+}
+
+// regenerate refreshes one user's recommendations and records the outcome.
+func (s *batchService) regenerate(job *batchJob, userID uint, currentCatalog string, opts models.RecommendationOptions) {
+	if currentCatalog != "" && s.upToDate(userID, currentCatalog) {
+		s.record(job, func(status *models.BatchJob) { status.Skipped++ })
+		return
+	}
+
+	_, err := s.recommendations.GenerateRecommendations(userID, opts)
+	s.record(job, func(status *models.BatchJob) {
+		if err == nil {
+			status.Succeeded++
+			return
+		}
+		status.Failed++
+		if len(status.Errors) < maxBatchErrors {
+			status.Errors = append(status.Errors, models.BatchJobError{UserID: userID, Error: err.Error()})
+		}
+	})
+}
+
+// upToDate reports whether the user's latest run already used the current
+// catalog and engine. Users without a run are never up to date.
+func (s *batchService) upToDate(userID uint, currentCatalog string) bool {
+	run, err := s.repos.RecommendationRun.GetLatestByUserID(userID)
+	if err != nil {
+		return false
+	}
+	return run.CatalogVersion == currentCatalog && run.EngineVersion == engineVersion
+}
+
+func (s *batchService) record(job *batchJob, update func(status *models.BatchJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(&job.status)
+	job.status.Processed++
+}
+
+func (s *batchService) GetJob(id uint) (*models.BatchJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("batch job %d not found", id)
+	}
+	status := job.snapshot()
+	return &status, nil
+}
+
+// ListJobs returns the retained jobs, newest first.
+func (s *batchService) ListJobs() []models.BatchJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]models.BatchJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.snapshot())
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID > jobs[j].ID
+	})
+	return jobs
+}
+
+// CancelJob asks a running job to stop. The job reports cancelled once the
+// regenerations underway finish; cancelling a finished job has no effect.
+func (s *batchService) CancelJob(id uint) (*models.BatchJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("batch job %d not found", id)
+	}
+	job.cancel()
+	status := job.snapshot()
+	return &status, nil
+}
+
+// pruneJobs drops the oldest finished jobs beyond retainBatchJobs. Callers
+// must hold the mutex.
+func (s *batchService) pruneJobs() {
+	if len(s.jobs) <= retainBatchJobs {
+		return
+	}
+	ids := make([]uint, 0, len(s.jobs))
+	for id := range s.jobs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if len(s.jobs) <= retainBatchJobs {
+			return
+		}
+		if s.jobs[id].status.Status != models.BatchJobRunning {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// snapshot copies the job status so it can be returned outside the mutex.
+func (job *batchJob) snapshot() models.BatchJob {
+	status := job.status
+	status.Errors = make([]models.BatchJobError, len(job.status.Errors))
+	copy(status.Errors, job.status.Errors)
+	return status
+}
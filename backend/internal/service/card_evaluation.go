@@ -0,0 +1,265 @@
+package service
+
+import (
+	"math"
+
+	"gotocard-backend/internal/models"
+)
+
+// categoryReward details how a card's monthly reward in one category was
+// reached, for ranking as well as for the score breakdown.
+type categoryReward struct {
+	benefit        *models.CardBenefit // the benefit earning most of the reward
+	spend          float64
+	gross          float64 // reward on the full spend before any cap
+	reward         float64 // reward after benefit, tier and shared caps
+	merchantReward float64 // part of reward earned by merchant benefits
+	minSpendMet    bool
+
+	// Spend overseas, the FX fees charged on it (already deducted from
+	// gross and reward) and whether benefit is the card's overseas rate
+	overseasSpend float64
+	fxFees        float64
+	overseasRate  bool
+
+	// Points converted to miles through a transfer partner
+	transferPartner *models.TransferPartner
+	transferMiles   float64 // monthly miles received
+	conversionFee   float64 // annual conversion fees charged to the category
+
+	// Spread of the reward over months drawn from the spending history,
+	// nil without history
+	rewardRange *rewardRange
+}
+
+// cardEvaluation is a card's result against a whole spending profile.
+type cardEvaluation struct {
+	categories map[uint]categoryReward
+	totalSpend float64
+}
+
+// benefitSpend is the part of a category's monthly spend earning a benefit.
+type benefitSpend struct {
+	benefit  *models.CardBenefit
+	spend    float64
+	overseas bool // the benefit is the card's overseas rate
+}
+
+// evaluateCard computes the monthly reward a card earns in each category when
+// the given spending profile is charged to it. Spend at merchants the card
+// has a merchant benefit for earns that benefit, overseas spend earns the
+// card's overseas rate if it has one, and the rest of the category earns the
+// category benefit. FX fees on overseas spend are deducted from the reward.
+// Unlike calculateReward it applies card-level rules that span categories:
+// the card's minimum total spend and reward caps shared by a group of
+// benefits.
+func (s *recommendationService) evaluateCard(card models.CreditCard, categorySpending map[uint]float64, split spendingSplit) cardEvaluation {
+	evaluation := cardEvaluation{categories: make(map[uint]categoryReward)}
+
+	for _, spent := range categorySpending {
+		evaluation.totalSpend += spent
+	}
+	cardMinSpendMet := card.Rules.MinTotalSpend <= 0 || evaluation.totalSpend >= card.Rules.MinTotalSpend
+
+	// Reward earned towards each cap group and through each transfer
+	// partner, per category
+	groupRewards := make(map[uint]float64)
+	groupShares := make(map[uint]map[uint]float64)
+	transfers := make(map[uint]*transferredReward)
+	for categoryID, spent := range categorySpending {
+		overseas := math.Min(split.overseas[categoryID], spent)
+		portions := splitCategorySpend(card, categoryID, spent, split.merchants[categoryID], overseas)
+		if len(portions) == 0 {
+			continue
+		}
+
+		result := categoryReward{spend: spent, minSpendMet: cardMinSpendMet, overseasSpend: overseas}
+		bestReward := -1.0
+		for _, portion := range portions {
+			valuation := valuationFor(card, portion.benefit)
+			result.gross += s.calculateReward(portion.spend, uncappedBenefit(portion.benefit), valuation)
+
+			var reward float64
+			if cardMinSpendMet {
+				reward = s.calculateReward(portion.spend, portion.benefit, valuation)
+			}
+			result.reward += reward
+			switch {
+			case len(portion.benefit.Merchants) > 0:
+				result.merchantReward += reward
+			case !portion.overseas:
+				result.minSpendMet = cardMinSpendMet && portion.spend >= portion.benefit.MinSpend
+			}
+			// Ties go to the category benefit, which comes last
+			if reward >= bestReward {
+				result.benefit, bestReward = portion.benefit, reward
+				result.overseasRate = portion.overseas
+			}
+
+			if valuation.transfer != nil && reward > 0 && earnsPoints(portion.benefit) {
+				transfer, ok := transfers[valuation.transfer.ID]
+				if !ok {
+					transfer = &transferredReward{
+						partner:          valuation.transfer,
+						directPointCents: valuation.directPointCents,
+						categories:       make(map[uint]float64),
+					}
+					transfers[valuation.transfer.ID] = transfer
+				}
+				transfer.categories[categoryID] += reward
+			}
+
+			if portion.benefit.CapGroupID != nil && reward > 0 {
+				groupID := *portion.benefit.CapGroupID
+				groupRewards[groupID] += reward
+				if groupShares[groupID] == nil {
+					groupShares[groupID] = make(map[uint]float64)
+				}
+				groupShares[groupID][categoryID] += reward
+			}
+		}
+
+		evaluation.categories[categoryID] = result
+	}
+
+	// Scale down every member of an exceeded cap group pro rata
+	for _, group := range card.CapGroups {
+		total := groupRewards[group.ID]
+		if group.RewardCap <= 0 || total <= group.RewardCap {
+			continue
+		}
+
+		scale := group.RewardCap / total
+		for categoryID, share := range groupShares[group.ID] {
+			result := evaluation.categories[categoryID]
+			categoryScale := 1 - share*(1-scale)/result.reward
+			result.reward -= share * (1 - scale)
+			if result.merchantReward > result.reward {
+				result.merchantReward = result.reward
+			}
+			evaluation.categories[categoryID] = result
+
+			// Capped rewards earn proportionally fewer points to transfer
+			for _, transfer := range transfers {
+				transfer.categories[categoryID] *= categoryScale
+			}
+		}
+	}
+
+	applyConversionCosts(&evaluation, transfers)
+	applyFXFees(&evaluation, card)
+	return evaluation
+}
+
+// applyFXFees deducts the card's FX fee on each category's overseas spend.
+// It runs after caps so the fee is charged on the whole amount.
+func applyFXFees(evaluation *cardEvaluation, card models.CreditCard) {
+	if card.Overseas.FXFeeRate <= 0 {
+		return
+	}
+	for categoryID, result := range evaluation.categories {
+		if result.overseasSpend <= 0 {
+			continue
+		}
+		result.fxFees = result.overseasSpend * card.Overseas.FXFeeRate / 100
+		result.gross -= result.fxFees
+		result.reward -= result.fxFees
+		evaluation.categories[categoryID] = result
+	}
+}
+
+// splitCategorySpend divides a category's monthly spend between the card's
+// merchant benefits, its overseas rate and its category benefit. Spend at the
+// same benefit is combined so its cap applies once. Merchant spend recorded
+// in excess of the category's spend is scaled down to fit, and overseas spend
+// comes out of what merchant benefits leave.
+func splitCategorySpend(card models.CreditCard, categoryID uint, spent float64, merchants map[uint]float64, overseas float64) []benefitSpend {
+	var merchantTotal float64
+	for _, amount := range merchants {
+		merchantTotal += amount
+	}
+	scale := 1.0
+	if merchantTotal > spent && merchantTotal > 0 {
+		scale = spent / merchantTotal
+	}
+
+	var portions []benefitSpend
+	remaining := spent
+	for merchantID, amount := range merchants {
+		benefit := benefitForMerchant(card, merchantID)
+		if benefit == nil {
+			continue
+		}
+
+		amount *= scale
+		remaining -= amount
+		merged := false
+		for i := range portions {
+			if portions[i].benefit == benefit {
+				portions[i].spend += amount
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			portions = append(portions, benefitSpend{benefit: benefit, spend: amount})
+		}
+	}
+
+	if benefit := overseasBenefit(card, categoryID); benefit != nil && overseas > 0 {
+		amount := math.Min(overseas, math.Max(0, remaining))
+		remaining -= amount
+		portions = append(portions, benefitSpend{benefit: benefit, spend: amount, overseas: true})
+	}
+
+	if benefit := benefitForCategory(card, categoryID); benefit != nil {
+		portions = append(portions, benefitSpend{benefit: benefit, spend: math.Max(0, remaining)})
+	}
+	return portions
+}
+
+// overseasBenefit returns the card's overseas rate as a benefit for the
+// category, or nil if the card has none. Excluded categories earn nothing
+// overseas either.
+func overseasBenefit(card models.CreditCard, categoryID uint) *models.CardBenefit {
+	rates := card.Overseas
+	if rates.CashbackRate <= 0 && rates.PointsRate <= 0 && rates.MilesRate <= 0 {
+		return nil
+	}
+	for _, category := range card.ExcludedCategories {
+		if category.ID == categoryID {
+			return nil
+		}
+	}
+	return &models.CardBenefit{
+		CardID:       card.ID,
+		CategoryID:   categoryID,
+		CashbackRate: rates.CashbackRate,
+		PointsRate:   rates.PointsRate,
+		MilesRate:    rates.MilesRate,
+		Description:  "Overseas rate on foreign currency spend",
+	}
+}
+
+// evaluateCards runs evaluateCard for every card, keyed by card ID.
+func (s *recommendationService) evaluateCards(cards []models.CreditCard, categorySpending map[uint]float64, split spendingSplit) map[uint]cardEvaluation {
+	evaluations := make(map[uint]cardEvaluation, len(cards))
+	for _, card := range cards {
+		evaluations[card.ID] = s.evaluateCard(card, categorySpending, split)
+	}
+	return evaluations
+}
+
+// uncappedBenefit returns a copy of the benefit with its minimum spend and
+// all caps removed, used to measure the reward lost to those limits.
+func uncappedBenefit(benefit *models.CardBenefit) *models.CardBenefit {
+	uncapped := *benefit
+	uncapped.MinSpend = 0
+	uncapped.Cap = 0
+	uncapped.Tiers = make([]models.BenefitTier, len(benefit.Tiers))
+	for i, tier := range benefit.Tiers {
+		tier.Cap = 0
+		uncapped.Tiers[i] = tier
+	}
+	return &uncapped
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"gotocard-backend/internal/models"
+)
+
+// minimumCardholderAge applies to cards that do not set their own MinAge;
+// principal cardholders in Singapore must be at least 21.
+const minimumCardholderAge = 21
+
+// checkEligibility returns the reasons the user cannot apply for the card,
+// or an empty string if they can. Profile fields the user has not filled in
+// are not checked.
+func checkEligibility(profile models.UserProfile, card models.CreditCard) string {
+	var reasons []string
+
+	if profile.AnnualIncome > 0 {
+		minIncome := card.MinIncome
+		if profile.ResidencyStatus == models.ResidencyForeigner && card.Eligibility.MinIncomeForeigner > 0 {
+			minIncome = card.Eligibility.MinIncomeForeigner
+		}
+		if profile.AnnualIncome < minIncome {
+			reasons = append(reasons, fmt.Sprintf("requires annual income of $%.0f (yours: $%.0f)", minIncome, profile.AnnualIncome))
+		}
+	}
+
+	if profile.Age > 0 {
+		minAge := card.Eligibility.MinAge
+		if minAge == 0 {
+			minAge = minimumCardholderAge
+		}
+		if profile.Age < minAge {
+			reasons = append(reasons, fmt.Sprintf("requires minimum age of %d", minAge))
+		}
+		if card.Eligibility.MaxAge > 0 && profile.Age > card.Eligibility.MaxAge {
+			reasons = append(reasons, fmt.Sprintf("requires maximum age of %d", card.Eligibility.MaxAge))
+		}
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+// eligibleCards filters out the cards the user cannot apply for.
+func eligibleCards(profile models.UserProfile, cards []models.CreditCard) []models.CreditCard {
+	var eligible []models.CreditCard
+	for _, card := range cards {
+		if checkEligibility(profile, card) == "" {
+			eligible = append(eligible, card)
+		}
+	}
+	return eligible
+}
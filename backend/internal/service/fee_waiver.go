@@ -0,0 +1,57 @@
+package service
+
+import (
+	"math"
+
+	"gotocard-backend/internal/models"
+)
+
+// horizonFees is a card's expected annual fee cost for each value horizon.
+type horizonFees struct {
+	firstYear float64
+	ongoing   float64
+	amortized float64
+}
+
+// expectedAnnualFee is the fee the user can expect to pay in the given card
+// year (1-based) for the projected annual spend on the card.
+func expectedAnnualFee(card models.CreditCard, year int, annualSpend float64) float64 {
+	rules := card.FeeWaiver
+	if card.AnnualFee <= 0 || year <= rules.FirstYearsWaived {
+		return 0
+	}
+	if rules.SpendThreshold > 0 && annualSpend >= rules.SpendThreshold {
+		return 0
+	}
+
+	probability := math.Max(0, math.Min(1, rules.AutoWaiverProbability))
+	return card.AnnualFee * (1 - probability)
+}
+
+// expectedFees projects the card's fee cost over each horizon. The
+// amortized horizon averages the first welcomeAmortizationYears years.
+func expectedFees(card models.CreditCard, annualSpend float64) horizonFees {
+	fees := horizonFees{
+		firstYear: expectedAnnualFee(card, 1, annualSpend),
+		ongoing:   expectedAnnualFee(card, card.FeeWaiver.FirstYearsWaived+1, annualSpend),
+	}
+
+	for year := 1; year <= welcomeAmortizationYears; year++ {
+		fees.amortized += expectedAnnualFee(card, year, annualSpend)
+	}
+	fees.amortized /= welcomeAmortizationYears
+
+	return fees
+}
+
+// forHorizon picks the expected fee for a value horizon.
+func (f horizonFees) forHorizon(horizon string) float64 {
+	switch horizon {
+	case models.HorizonFirstYear:
+		return f.firstYear
+	case models.HorizonAmortized:
+		return f.amortized
+	default:
+		return f.ongoing
+	}
+}
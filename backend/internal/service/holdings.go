@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"gotocard-backend/internal/models"
+)
+
+// walletBaseline is what the cards the user already holds earn on a spending
+// profile. New cards are valued by how much they add on top of it.
+type walletBaseline struct {
+	rewards map[uint]float64 // best monthly reward per category among held cards, negative after FX fees
+	cardIDs map[uint]bool
+	banks   map[string]bool
+}
+
+// currentBaseline evaluates the user's held cards, with the terms in effect
+// at asOf, against the spending profile and keeps the best reward in each
+// category. A held card that loses money on a category, such as overseas
+// spend after FX fees, still sets the baseline there.
+func (s *recommendationService) currentBaseline(userID uint, categorySpending map[uint]float64, split spendingSplit, asOf time.Time) (walletBaseline, error) {
+	baseline := walletBaseline{
+		rewards: make(map[uint]float64),
+		cardIDs: make(map[uint]bool),
+		banks:   make(map[string]bool),
+	}
+
+	userCards, err := s.repos.UserCard.GetByUserIDAsOf(userID, asOf)
+	if err != nil {
+		return baseline, fmt.Errorf("failed to get user cards: %w", err)
+	}
+
+	held := make([]models.CreditCard, 0, len(userCards))
+	for _, userCard := range userCards {
+		held = append(held, userCard.Card)
+		baseline.cardIDs[userCard.CardID] = true
+		baseline.banks[userCard.Card.Bank] = true
+	}
+
+	for _, evaluation := range s.evaluateCards(held, categorySpending, split) {
+		for categoryID, result := range evaluation.categories {
+			if best, ok := baseline.rewards[categoryID]; !ok || result.reward > best {
+				baseline.rewards[categoryID] = result.reward
+			}
+		}
+	}
+
+	return baseline, nil
+}
+
+// holdsCards reports whether the user holds any cards to compare against.
+func (b walletBaseline) holdsCards() bool {
+	return len(b.cardIDs) > 0
+}
+
+// newCards drops the cards the user already holds.
+func (b walletBaseline) newCards(cards []models.CreditCard) []models.CreditCard {
+	var fresh []models.CreditCard
+	for _, card := range cards {
+		if !b.cardIDs[card.ID] {
+			fresh = append(fresh, card)
+		}
+	}
+	return fresh
+}
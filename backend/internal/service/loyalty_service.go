@@ -0,0 +1,137 @@
+package service
+
+import (
+	"fmt"
+
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/repository"
+)
+
+type loyaltyProgramService struct {
+	repos *repository.Repositories
+}
+
+func NewLoyaltyProgramService(repos *repository.Repositories) LoyaltyProgramService {
+	return &loyaltyProgramService{repos: repos}
+}
+
+func (s *loyaltyProgramService) CreateLoyaltyProgram(program *models.LoyaltyProgram) error {
+	// Check if program already exists
+	existing, err := s.repos.LoyaltyProgram.GetByName(program.Name)
+	if err == nil && existing != nil {
+		return fmt.Errorf("loyalty program with name %s already exists", program.Name)
+	}
+
+	err = s.repos.LoyaltyProgram.Create(program)
+	if err != nil {
+		return fmt.Errorf("failed to create loyalty program: %w", err)
+	}
+	return nil
+}
+
+func (s *loyaltyProgramService) GetLoyaltyProgramByID(id uint) (*models.LoyaltyProgram, error) {
+	program, err := s.repos.LoyaltyProgram.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("loyalty program not found: %w", err)
+	}
+	return program, nil
+}
+
+func (s *loyaltyProgramService) UpdateLoyaltyProgram(program *models.LoyaltyProgram) error {
+	err := s.repos.LoyaltyProgram.Update(program)
+	if err != nil {
+		return fmt.Errorf("failed to update loyalty program: %w", err)
+	}
+	return nil
+}
+
+func (s *loyaltyProgramService) DeleteLoyaltyProgram(id uint) error {
+	err := s.repos.LoyaltyProgram.Delete(id)
+	if err != nil {
+		return fmt.Errorf("failed to delete loyalty program: %w", err)
+	}
+	return nil
+}
+
+func (s *loyaltyProgramService) ListLoyaltyPrograms() ([]models.LoyaltyProgram, error) {
+	programs, err := s.repos.LoyaltyProgram.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list loyalty programs: %w", err)
+	}
+	return programs, nil
+}
+
+func (s *loyaltyProgramService) CreateTransferPartner(partner *models.TransferPartner) error {
+	// Check if the programs are already partnered
+	existing, err := s.repos.TransferPartner.GetByPrograms(partner.FromProgramID, partner.ToProgramID)
+	if err == nil && existing != nil {
+		return fmt.Errorf("transfer partner from program %d to program %d already exists", partner.FromProgramID, partner.ToProgramID)
+	}
+
+	if err := s.checkTransferPrograms(partner); err != nil {
+		return err
+	}
+
+	err = s.repos.TransferPartner.Create(partner)
+	if err != nil {
+		return fmt.Errorf("failed to create transfer partner: %w", err)
+	}
+	return nil
+}
+
+func (s *loyaltyProgramService) GetTransferPartnerByID(id uint) (*models.TransferPartner, error) {
+	partner, err := s.repos.TransferPartner.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("transfer partner not found: %w", err)
+	}
+	return partner, nil
+}
+
+func (s *loyaltyProgramService) UpdateTransferPartner(partner *models.TransferPartner) error {
+	if err := s.checkTransferPrograms(partner); err != nil {
+		return err
+	}
+
+	err := s.repos.TransferPartner.Update(partner)
+	if err != nil {
+		return fmt.Errorf("failed to update transfer partner: %w", err)
+	}
+	return nil
+}
+
+func (s *loyaltyProgramService) DeleteTransferPartner(id uint) error {
+	err := s.repos.TransferPartner.Delete(id)
+	if err != nil {
+		return fmt.Errorf("failed to delete transfer partner: %w", err)
+	}
+	return nil
+}
+
+func (s *loyaltyProgramService) ListTransferPartners() ([]models.TransferPartner, error) {
+	partners, err := s.repos.TransferPartner.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfer partners: %w", err)
+	}
+	return partners, nil
+}
+
+// checkTransferPrograms verifies that a partner converts a points program
+// into a miles program.
+func (s *loyaltyProgramService) checkTransferPrograms(partner *models.TransferPartner) error {
+	from, err := s.repos.LoyaltyProgram.GetByID(partner.FromProgramID)
+	if err != nil {
+		return fmt.Errorf("loyalty program %d not found: %w", partner.FromProgramID, err)
+	}
+	if from.Type != models.LoyaltyTypePoints {
+		return fmt.Errorf("%s is not a points program", from.Name)
+	}
+
+	to, err := s.repos.LoyaltyProgram.GetByID(partner.ToProgramID)
+	if err != nil {
+		return fmt.Errorf("loyalty program %d not found: %w", partner.ToProgramID, err)
+	}
+	if to.Type != models.LoyaltyTypeMiles {
+		return fmt.Errorf("%s is not a miles program", to.Name)
+	}
+	return nil
+}
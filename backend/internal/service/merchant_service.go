@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/repository"
+)
+
+type merchantService struct {
+	repos *repository.Repositories
+}
+
+func NewMerchantService(repos *repository.Repositories) MerchantService {
+	return &merchantService{repos: repos}
+}
+
+func (s *merchantService) CreateMerchant(merchant *models.Merchant) error {
+	// Check if merchant already exists
+	existing, err := s.repos.Merchant.GetByName(merchant.Name)
+	if err == nil && existing != nil {
+		return fmt.Errorf("merchant with name %s already exists", merchant.Name)
+	}
+
+	if _, err := s.repos.Category.GetByID(merchant.CategoryID); err != nil {
+		return fmt.Errorf("category not found: %w", err)
+	}
+
+	err = s.repos.Merchant.Create(merchant)
+	if err != nil {
+		return fmt.Errorf("failed to create merchant: %w", err)
+	}
+	return nil
+}
+
+func (s *merchantService) GetMerchantByID(id uint) (*models.Merchant, error) {
+	merchant, err := s.repos.Merchant.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("merchant not found: %w", err)
+	}
+	return merchant, nil
+}
+
+func (s *merchantService) UpdateMerchant(merchant *models.Merchant) error {
+	if _, err := s.repos.Category.GetByID(merchant.CategoryID); err != nil {
+		return fmt.Errorf("category not found: %w", err)
+	}
+
+	err := s.repos.Merchant.Update(merchant)
+	if err != nil {
+		return fmt.Errorf("failed to update merchant: %w", err)
+	}
+	return nil
+}
+
+func (s *merchantService) DeleteMerchant(id uint) error {
+	err := s.repos.Merchant.Delete(id)
+	if err != nil {
+		return fmt.Errorf("failed to delete merchant: %w", err)
+	}
+	return nil
+}
+
+func (s *merchantService) ListMerchants() ([]models.Merchant, error) {
+	merchants, err := s.repos.Merchant.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merchants: %w", err)
+	}
+	return merchants, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"gotocard-backend/internal/config"
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/repository"
+)
+
+// NotificationChannel delivers notifications to users by one route. Enabled
+// reports whether the user wants notifications through the channel.
+type NotificationChannel interface {
+	Name() string
+	Enabled(user *models.User) bool
+	Deliver(user *models.User, notification *models.Notification) error
+}
+
+// Mailer sends a plain-text email.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// inboxChannel stores notifications for the in-app inbox. It runs first so
+// other channels see the stored notification's ID, and are skipped when it
+// cannot be saved.
+type inboxChannel struct {
+	repos *repository.Repositories
+}
+
+func (c *inboxChannel) Name() string { return "inbox" }
+
+func (c *inboxChannel) Enabled(user *models.User) bool { return true }
+
+func (c *inboxChannel) Deliver(user *models.User, notification *models.Notification) error {
+	return c.repos.Notification.Create(notification)
+}
+
+// emailChannel sends notifications to the user's email address.
+type emailChannel struct {
+	mailer Mailer
+}
+
+func (c *emailChannel) Name() string { return "email" }
+
+func (c *emailChannel) Enabled(user *models.User) bool {
+	return user.Preferences.Notifications.Email
+}
+
+func (c *emailChannel) Deliver(user *models.User, notification *models.Notification) error {
+	return c.mailer.Send(user.Email, notification.Title, notification.Message)
+}
+
+// webhookChannel posts notifications as JSON to the user's webhook URL.
+// Webhooks are user supplied, so only https URLs are called and the client
+// refuses to connect to loopback, private or link-local addresses.
+type webhookChannel struct {
+	client *http.Client
+}
+
+// errWebhookAddress is returned when a webhook resolves to an address the
+// server must not call.
+var errWebhookAddress = errors.New("webhook address is not public")
+
+// newWebhookClient builds a client that checks every address it connects
+// to, after DNS resolution and on redirects, so a public host name cannot
+// point the server at its own network.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errWebhookAddress
+			}
+			return nil
+		},
+	}
+	// No proxy: the address check only holds when the client dials itself
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("webhook redirected to %s", req.URL.Scheme)
+			}
+			if len(via) >= 3 {
+				return errors.New("webhook redirected too many times")
+			}
+			return nil
+		},
+	}
+}
+
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// webhookPayload is the body posted to a user's webhook.
+type webhookPayload struct {
+	Event        string              `json:"event"`
+	Notification models.Notification `json:"notification"`
+}
+
+func (c *webhookChannel) Name() string { return "webhook" }
+
+func (c *webhookChannel) Enabled(user *models.User) bool {
+	return user.Preferences.Notifications.WebhookURL != ""
+}
+
+func (c *webhookChannel) Deliver(user *models.User, notification *models.Notification) error {
+	target, err := url.Parse(user.Preferences.Notifications.WebhookURL)
+	if err != nil || target.Scheme != "https" {
+		return fmt.Errorf("webhook URL must use https")
+	}
+
+	body, err := json.Marshal(webhookPayload{Event: "better_card", Notification: *notification})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	resp, err := c.client.Post(user.Preferences.Notifications.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// smtpMailer sends email through an SMTP server, authenticating when a
+// username is configured.
+type smtpMailer struct {
+	cfg config.NotificationConfig
+}
+
+func (m *smtpMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", m.cfg.SMTPUsername, m.cfg.SMTPPassword, m.cfg.SMTPHost)
+	}
+
+	message := strings.Join([]string{
+		"From: " + m.cfg.SMTPFrom,
+		"To: " + to,
+		"Subject: " + subject,
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	addr := m.cfg.SMTPHost + ":" + m.cfg.SMTPPort
+	if err := smtp.SendMail(addr, auth, m.cfg.SMTPFrom, []string{to}, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// defaultNotificationChannels builds the inbox, email and webhook channels
+// from the configuration. Email is left out without an SMTP host.
+func defaultNotificationChannels(repos *repository.Repositories, cfg config.NotificationConfig) []NotificationChannel {
+	channels := []NotificationChannel{&inboxChannel{repos: repos}}
+	if cfg.SMTPHost != "" {
+		channels = append(channels, &emailChannel{mailer: &smtpMailer{cfg: cfg}})
+	}
+	channels = append(channels, &webhookChannel{
+		client: newWebhookClient(time.Duration(cfg.WebhookTimeout) * time.Second),
+	})
+	return channels
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gotocard-backend/internal/config"
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/repository"
+)
+
+type notificationService struct {
+	repos           *repository.Repositories
+	recommendations RecommendationService
+	channels        []NotificationChannel
+	minImprovement  float64
+}
+
+func NewNotificationService(repos *repository.Repositories, recommendations RecommendationService, cfg config.NotificationConfig) NotificationService {
+	return &notificationService{
+		repos:           repos,
+		recommendations: recommendations,
+		channels:        defaultNotificationChannels(repos, cfg),
+		minImprovement:  cfg.MinImprovement,
+	}
+}
+
+// cardImprovement is a changed card that beats the user's stored
+// recommendations for a category.
+type cardImprovement struct {
+	recommendation models.RecommendationResponse
+	current        *models.RecommendationResponse // best stored card, nil if none
+	improvement    float64                        // annual net benefit gained
+}
+
+// NotifyCatalogChange re-evaluates every user with stored recommendations
+// against the current catalog and notifies those for whom one of the changed
+// cards beats their stored recommendations by at least their threshold.
+// Failures for one user are logged and do not stop the others.
+func (s *notificationService) NotifyCatalogChange(cardIDs []uint) error {
+	if len(cardIDs) == 0 {
+		return nil
+	}
+	changed := make(map[uint]bool, len(cardIDs))
+	for _, cardID := range cardIDs {
+		changed[cardID] = true
+	}
+
+	users, err := s.repos.User.List()
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	notified := 0
+	for i := range users {
+		user := &users[i]
+		if user.Preferences.Notifications.Muted {
+			continue
+		}
+
+		improvements, err := s.findImprovements(user, changed)
+		if err != nil {
+			log.Printf("Failed to check user %d for better cards: %v", user.ID, err)
+			continue
+		}
+		for _, improvement := range improvements {
+			if s.notify(user, improvement) {
+				notified++
+			}
+		}
+	}
+
+	log.Printf("Checked %d users against %d changed cards, sent %d notifications", len(users), len(cardIDs), notified)
+	return nil
+}
+
+// findImprovements compares a fresh ranking with the user's latest stored
+// run, using the options that run was generated with. Each changed card is
+// reported once, for the category where it gains the most. Users without a
+// stored run are skipped.
+func (s *notificationService) findImprovements(user *models.User, changed map[uint]bool) ([]cardImprovement, error) {
+	run, err := s.repos.RecommendationRun.GetLatestByUserID(user.ID)
+	if err != nil {
+		return nil, nil
+	}
+
+	runResponse, err := s.recommendations.GetRecommendationRun(user.ID, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	stored := runResponse.Recommendations
+	bestStored := make(map[uint]*models.RecommendationResponse)
+	for i, rec := range stored {
+		if !rec.Eligible {
+			continue
+		}
+		if best, ok := bestStored[rec.Category.ID]; !ok || rec.Breakdown.NetBenefit > best.Breakdown.NetBenefit {
+			bestStored[rec.Category.ID] = &stored[i]
+		}
+	}
+
+	opts := run.Options
+	opts.AsOf = nil
+	opts.IncludeIneligible = false
+	fresh, err := s.recommendations.PreviewRecommendations(user.ID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := user.Preferences.Notifications.MinImprovement
+	if threshold <= 0 {
+		threshold = s.minImprovement
+	}
+
+	byCard := make(map[uint]cardImprovement)
+	var order []uint
+	for _, rec := range fresh {
+		if !changed[rec.Card.ID] || !rec.Eligible {
+			continue
+		}
+
+		current := bestStored[rec.Category.ID]
+		improvement := rec.Breakdown.NetBenefit
+		if current != nil {
+			if current.Card.ID == rec.Card.ID {
+				continue
+			}
+			improvement -= current.Breakdown.NetBenefit
+		}
+		if improvement < threshold {
+			continue
+		}
+
+		existing, seen := byCard[rec.Card.ID]
+		if !seen {
+			order = append(order, rec.Card.ID)
+		}
+		if !seen || improvement > existing.improvement {
+			byCard[rec.Card.ID] = cardImprovement{recommendation: rec, current: current, improvement: improvement}
+		}
+	}
+
+	improvements := make([]cardImprovement, 0, len(order))
+	for _, cardID := range order {
+		improvements = append(improvements, byCard[cardID])
+	}
+	return improvements, nil
+}
+
+// notify delivers one improvement through every channel the user enabled,
+// unless the user was already told about the card's current terms. A card
+// whose terms changed since is announced again. The stored notification
+// is what marks the user as told, so if the inbox cannot save it nothing
+// else is sent and the next scrape tries again. It reports whether the
+// notification was stored.
+func (s *notificationService) notify(user *models.User, improvement cardImprovement) bool {
+	rec := improvement.recommendation
+	cardVersion := catalogVersion([]models.CreditCard{rec.Card})
+	exists, err := s.repos.Notification.ExistsForUserAndCard(user.ID, rec.Card.ID, cardVersion)
+	if err != nil {
+		log.Printf("Failed to check notifications for user %d: %v", user.ID, err)
+		return false
+	}
+	if exists {
+		return false
+	}
+
+	notification := &models.Notification{
+		UserID:      user.ID,
+		CardID:      rec.Card.ID,
+		CardVersion: cardVersion,
+		CategoryID:  rec.Category.ID,
+		Title:       fmt.Sprintf("A better card for %s", rec.Category.Name),
+		Message:     improvementMessage(improvement),
+		Improvement: roundCents(improvement.improvement),
+		Card:        rec.Card,
+		Category:    rec.Category,
+	}
+
+	for _, channel := range s.channels {
+		if !channel.Enabled(user) {
+			continue
+		}
+		if err := channel.Deliver(user, notification); err != nil {
+			log.Printf("Failed to deliver %s notification to user %d: %v", channel.Name(), user.ID, err)
+			if channel.Name() == "inbox" {
+				return false
+			}
+		}
+	}
+	return notification.ID != 0
+}
+
+func improvementMessage(improvement cardImprovement) string {
+	rec := improvement.recommendation
+	message := fmt.Sprintf("%s from %s could add $%.2f a year in net benefit on %s",
+		rec.Card.Name, rec.Card.Bank, improvement.improvement, rec.Category.Name)
+	if improvement.current != nil {
+		message += fmt.Sprintf(" over %s, your current top recommendation", improvement.current.Card.Name)
+	}
+	return message + ". " + rec.Reason
+}
+
+func (s *notificationService) ListNotifications(userID uint, opts models.NotificationListOptions) ([]models.Notification, error) {
+	return s.repos.Notification.ListByUserID(userID, opts.Unread)
+}
+
+// MarkNotificationRead marks one of the user's notifications as read; marking
+// it again keeps the first read time.
+func (s *notificationService) MarkNotificationRead(userID, notificationID uint) (*models.Notification, error) {
+	notification, err := s.repos.Notification.GetByID(notificationID)
+	if err != nil || notification.UserID != userID {
+		return nil, fmt.Errorf("notification %d not found for user %d", notificationID, userID)
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := s.repos.Notification.Update(notification); err != nil {
+			return nil, fmt.Errorf("failed to update notification: %w", err)
+		}
+	}
+	return notification, nil
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gotocard-backend/internal/models"
+)
+
+// ChooseCard ranks the user's held cards by the reward a purchase adds on top
+// of the spend already charged to each card in the purchase's month, so caps
+// already used up and minimum spend the purchase reaches are accounted for.
+// Only spend recorded against a card counts towards its month.
+func (s *recommendationService) ChooseCard(userID uint, req models.PurchaseRequest) (*models.PurchaseResponse, error) {
+	if _, err := s.repos.User.GetByID(userID); err != nil {
+		return nil, lookupError("user", err)
+	}
+
+	date := time.Now()
+	if req.Date != nil {
+		date = *req.Date
+	}
+
+	// A purchase at a merchant falls in the merchant's category unless given one
+	var merchant *models.Merchant
+	categoryID := req.CategoryID
+	if req.MerchantID != nil {
+		found, err := s.repos.Merchant.GetByID(*req.MerchantID)
+		if err != nil {
+			return nil, lookupError("merchant", err)
+		}
+		merchant = found
+		if categoryID == 0 {
+			categoryID = merchant.CategoryID
+		}
+	}
+	category, err := s.repos.Category.GetByID(categoryID)
+	if err != nil {
+		return nil, lookupError("category", err)
+	}
+
+	userCards, err := s.repos.UserCard.GetByUserIDAsOf(userID, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user cards: %w", err)
+	}
+	spendings, err := s.repos.UserSpending.GetByUserAndMonth(userID, int(date.Month()), date.Year())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user spending: %w", err)
+	}
+	spendings = preferLedgerSpending(spendings)
+
+	purchase := models.UserSpending{
+		UserID:     userID,
+		CategoryID: categoryID,
+		MerchantID: req.MerchantID,
+		Amount:     req.Amount,
+		Overseas:   req.Overseas,
+	}
+
+	choices := make([]models.CardChoice, 0, len(userCards))
+	for _, userCard := range userCards {
+		choices = append(choices, s.chooseCard(userCard.Card, spendings, purchase))
+	}
+
+	sort.SliceStable(choices, func(i, j int) bool {
+		return choices[i].MarginalReward > choices[j].MarginalReward
+	})
+
+	return &models.PurchaseResponse{
+		Category: *category,
+		Merchant: merchant,
+		Amount:   req.Amount,
+		Date:     date,
+		Cards:    choices,
+	}, nil
+}
+
+// chooseCard values a purchase on one card as the difference between the
+// card's monthly reward with and without it.
+func (s *recommendationService) chooseCard(card models.CreditCard, spendings []models.UserSpending, purchase models.UserSpending) models.CardChoice {
+	var charged []models.UserSpending
+	for _, spending := range spendings {
+		if spending.CardID != nil && *spending.CardID == card.ID {
+			charged = append(charged, spending)
+		}
+	}
+
+	beforeSpending, beforeSplit := monthSpending(charged)
+	afterSpending, afterSplit := monthSpending(append(charged, purchase))
+	before := s.evaluateCard(card, beforeSpending, beforeSplit)
+	after := s.evaluateCard(card, afterSpending, afterSplit)
+
+	choice := models.CardChoice{
+		Card:           card,
+		MarginalReward: roundCents(totalReward(after) - totalReward(before)),
+	}
+	choice.EffectiveRate = roundCents(choice.MarginalReward / purchase.Amount * 100)
+	if purchase.Overseas {
+		choice.FXFee = roundCents(purchase.Amount * card.Overseas.FXFeeRate / 100)
+	}
+
+	benefit, overseasRate := purchaseBenefit(card, purchase)
+	if benefit == nil {
+		choice.Reason = purchaseReason(choice, nil, false, purchase.Amount)
+		return choice
+	}
+
+	categoryID := purchase.CategoryID
+	portions := splitCategorySpend(card, categoryID, beforeSpending[categoryID], beforeSplit.merchants[categoryID], math.Min(beforeSplit.overseas[categoryID], beforeSpending[categoryID]))
+	for _, portion := range portions {
+		if portion.benefit == benefit || (isBaseRate(benefit) && isBaseRate(portion.benefit) && portion.overseas == overseasRate) {
+			choice.BenefitSpend = roundCents(portion.spend)
+			break
+		}
+	}
+
+	spent := choice.BenefitSpend + purchase.Amount
+	choice.Cap = benefit.Cap
+	if benefit.Cap > 0 {
+		remaining := roundCents(math.Max(0, benefit.Cap-spent))
+		choice.CapRemaining = &remaining
+	}
+	choice.MinSpend = benefit.MinSpend
+	choice.MinSpendMet = spent >= benefit.MinSpend &&
+		(card.Rules.MinTotalSpend <= 0 || after.totalSpend >= card.Rules.MinTotalSpend)
+	choice.Reason = purchaseReason(choice, benefit, overseasRate, purchase.Amount)
+	return choice
+}
+
+// monthSpending sums spending records into the category totals and split
+// evaluateCard takes, without averaging.
+func monthSpending(records []models.UserSpending) (map[uint]float64, spendingSplit) {
+	categories := make(map[uint]float64)
+	split := spendingSplit{merchants: make(merchantSpending), overseas: make(map[uint]float64)}
+	for _, record := range records {
+		categories[record.CategoryID] += record.Amount
+		if record.MerchantID != nil {
+			if split.merchants[record.CategoryID] == nil {
+				split.merchants[record.CategoryID] = make(map[uint]float64)
+			}
+			split.merchants[record.CategoryID][*record.MerchantID] += record.Amount
+		}
+		if record.Overseas {
+			split.overseas[record.CategoryID] += record.Amount
+		}
+	}
+	return categories, split
+}
+
+func totalReward(evaluation cardEvaluation) float64 {
+	var total float64
+	for _, result := range evaluation.categories {
+		total += result.reward
+	}
+	return total
+}
+
+// purchaseBenefit returns the benefit a purchase earns on the card, in the
+// order splitCategorySpend applies them, and whether it is the card's
+// overseas rate. It returns nil if the purchase earns nothing.
+func purchaseBenefit(card models.CreditCard, purchase models.UserSpending) (*models.CardBenefit, bool) {
+	if purchase.MerchantID != nil {
+		if benefit := benefitForMerchant(card, *purchase.MerchantID); benefit != nil {
+			return benefit, false
+		}
+	}
+	if purchase.Overseas {
+		if benefit := overseasBenefit(card, purchase.CategoryID); benefit != nil {
+			return benefit, true
+		}
+	}
+	return benefitForCategory(card, purchase.CategoryID), false
+}
+
+func purchaseReason(choice models.CardChoice, benefit *models.CardBenefit, overseasRate bool, amount float64) string {
+	if benefit == nil {
+		reason := "Earns nothing in this category"
+		if choice.FXFee > 0 {
+			reason += fmt.Sprintf(" and costs a $%.2f FX fee", choice.FXFee)
+		}
+		return reason
+	}
+
+	reason := fmt.Sprintf("Adds $%.2f (%.2f%% back)", choice.MarginalReward, choice.EffectiveRate)
+	switch {
+	case len(benefit.Merchants) > 0:
+		reason += " with the merchant offer"
+	case overseasRate:
+		reason += " at the overseas rate"
+	case isBaseRate(benefit):
+		reason += " at the base rate"
+	}
+	if choice.FXFee > 0 {
+		reason += fmt.Sprintf(" after a $%.2f FX fee", choice.FXFee)
+	}
+
+	if choice.CapRemaining != nil {
+		if *choice.CapRemaining > 0 {
+			reason += fmt.Sprintf(", $%.2f of the $%.0f monthly cap left afterwards", *choice.CapRemaining, choice.Cap)
+		} else {
+			reason += fmt.Sprintf(", the $%.0f monthly cap is reached", choice.Cap)
+		}
+	}
+	shortfall := choice.MinSpend - choice.BenefitSpend - amount
+	switch {
+	case shortfall > 0:
+		reason += fmt.Sprintf(", $%.2f more spend needed to reach the $%.0f minimum", shortfall, choice.MinSpend)
+	case !choice.MinSpendMet:
+		reason += ", the card's minimum total spend is not reached"
+	case choice.MinSpend > 0:
+		reason += fmt.Sprintf(", $%.0f minimum spend met", choice.MinSpend)
+	}
+	return reason
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gotocard-backend/internal/models"
+)
+
+// defaultRunListLimit is the number of runs listed when no limit is given
+const defaultRunListLimit = 20
+
+// ListRecommendationRuns returns the user's recommendation runs, newest
+// first, without their recommendations.
+func (s *recommendationService) ListRecommendationRuns(userID uint, opts models.RunListOptions) ([]models.RecommendationRun, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultRunListLimit
+	}
+
+	runs, err := s.repos.RecommendationRun.ListByUserID(userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recommendation runs: %w", err)
+	}
+	return runs, nil
+}
+
+// GetRecommendationRun returns one of the user's runs with its ranked
+// recommendations.
+func (s *recommendationService) GetRecommendationRun(userID, runID uint) (*models.RecommendationRunResponse, error) {
+	run, err := s.userRun(userID, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := recommendationResponses(run.Recommendations)
+	run.Recommendations = nil
+	return &models.RecommendationRunResponse{
+		Run:             *run,
+		Recommendations: recommendations,
+	}, nil
+}
+
+// DiffRecommendationRuns compares two of the user's runs: the (card,
+// category) recommendations that entered or left, and how the score and rank
+// of the ones in both runs changed.
+func (s *recommendationService) DiffRecommendationRuns(userID uint, req models.RunDiffRequest) (*models.RunDiff, error) {
+	toID := req.To
+	if toID == 0 {
+		latest, err := s.repos.RecommendationRun.GetLatestByUserID(userID)
+		if err != nil {
+			return nil, lookupError(fmt.Sprintf("latest recommendation run for user %d", userID), err)
+		}
+		toID = latest.ID
+	}
+
+	from, err := s.userRun(userID, req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.userRun(userID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	type rankedRecommendation struct {
+		rank int
+		rec  models.Recommendation
+	}
+	key := func(rec models.Recommendation) string {
+		return fmt.Sprintf("%d:%d", rec.CardID, rec.CategoryID)
+	}
+	before := make(map[string]rankedRecommendation, len(from.Recommendations))
+	for i, rec := range from.Recommendations {
+		before[key(rec)] = rankedRecommendation{rank: i + 1, rec: rec}
+	}
+
+	diff := &models.RunDiff{
+		SpendingChanged: from.SpendingHash != to.SpendingHash,
+		CatalogChanged:  from.CatalogVersion != to.CatalogVersion,
+		EngineChanged:   from.EngineVersion != to.EngineVersion,
+		Entered:         []models.RecommendationResponse{},
+		Left:            []models.RecommendationResponse{},
+		ScoreChanges:    []models.ScoreChange{},
+	}
+
+	for i, rec := range to.Recommendations {
+		previous, ok := before[key(rec)]
+		if !ok {
+			diff.Entered = append(diff.Entered, recommendationResponse(rec))
+			continue
+		}
+		delete(before, key(rec))
+
+		diff.ScoreChanges = append(diff.ScoreChanges, models.ScoreChange{
+			CardID:       rec.CardID,
+			CardName:     rec.Card.Name,
+			CategoryID:   rec.CategoryID,
+			CategoryName: rec.Category.Name,
+			FromScore:    previous.rec.Score,
+			ToScore:      rec.Score,
+			Delta:        roundCents(rec.Score - previous.rec.Score),
+			FromRank:     previous.rank,
+			ToRank:       i + 1,
+		})
+	}
+
+	// Whatever was not matched left the recommendations; keep its old order
+	var left []rankedRecommendation
+	for _, previous := range before {
+		left = append(left, previous)
+	}
+	sort.Slice(left, func(i, j int) bool {
+		return left[i].rank < left[j].rank
+	})
+	for _, previous := range left {
+		diff.Left = append(diff.Left, recommendationResponse(previous.rec))
+	}
+
+	from.Recommendations, to.Recommendations = nil, nil
+	diff.From, diff.To = *from, *to
+	return diff, nil
+}
+
+// userRun loads a run and checks that it belongs to the user.
+func (s *recommendationService) userRun(userID, runID uint) (*models.RecommendationRun, error) {
+	what := fmt.Sprintf("recommendation run %d", runID)
+	run, err := s.repos.RecommendationRun.GetByID(runID)
+	if err != nil {
+		return nil, lookupError(what, err)
+	}
+	if run.UserID != userID {
+		return nil, fmt.Errorf("%s %w", what, ErrNotFound)
+	}
+	return run, nil
+}
+
+// pruneRecommendationRuns applies the retention settings to the user's runs.
+func (s *recommendationService) pruneRecommendationRuns(userID uint) error {
+	var before time.Time
+	if s.retention.RetainDays > 0 {
+		before = time.Now().AddDate(0, 0, -s.retention.RetainDays)
+	}
+	if s.retention.RetainRuns <= 0 && before.IsZero() {
+		return nil
+	}
+	return s.repos.RecommendationRun.Prune(userID, s.retention.RetainRuns, before)
+}
+
+func recommendationResponse(rec models.Recommendation) models.RecommendationResponse {
+	return models.RecommendationResponse{
+		ID:               rec.ID,
+		Card:             rec.Card,
+		Category:         rec.Category,
+		Score:            rec.Score,
+		EstimatedReward:  rec.EstimatedReward,
+		Reason:           rec.Reason,
+		Breakdown:        rec.Breakdown,
+		Strategy:         rec.Strategy,
+		Eligible:         rec.Eligible,
+		IneligibleReason: rec.IneligibleReason,
+	}
+}
+
+func recommendationResponses(recs []models.Recommendation) []models.RecommendationResponse {
+	responses := make([]models.RecommendationResponse, 0, len(recs))
+	for _, rec := range recs {
+		responses = append(responses, recommendationResponse(rec))
+	}
+	return responses
+}
+
+// spendingHash fingerprints a monthly spending profile and its merchant and
+// overseas split, so runs over the same spending can be recognised.
+func spendingHash(categorySpending map[uint]float64, split spendingSplit) string {
+	lines := make([]string, 0, len(categorySpending))
+	for categoryID, amount := range categorySpending {
+		lines = append(lines, fmt.Sprintf("%d=%.2f", categoryID, amount))
+	}
+	for categoryID, amounts := range split.merchants {
+		for merchantID, amount := range amounts {
+			lines = append(lines, fmt.Sprintf("%d/%d=%.2f", categoryID, merchantID, amount))
+		}
+	}
+	for categoryID, amount := range split.overseas {
+		lines = append(lines, fmt.Sprintf("%d/overseas=%.2f", categoryID, amount))
+	}
+	return fingerprint(lines)
+}
+
+// catalogVersion fingerprints the card catalog a run was ranked against from
+// the last update of every card and the rules attached to it.
+func catalogVersion(cards []models.CreditCard) string {
+	var lines []string
+	for _, card := range cards {
+		lines = append(lines, fmt.Sprintf("card:%d@%d", card.ID, card.UpdatedAt.UnixNano()))
+		for _, benefit := range card.CardBenefits {
+			lines = append(lines, fmt.Sprintf("benefit:%d@%d", benefit.ID, benefit.UpdatedAt.UnixNano()))
+			for _, tier := range benefit.Tiers {
+				lines = append(lines, fmt.Sprintf("tier:%d@%d", tier.ID, tier.UpdatedAt.UnixNano()))
+			}
+			for _, merchant := range benefit.Merchants {
+				lines = append(lines, fmt.Sprintf("benefit_merchant:%d:%d", benefit.ID, merchant.ID))
+			}
+			if benefit.LoyaltyProgram != nil {
+				lines = append(lines, programVersion(benefit.LoyaltyProgram)...)
+			}
+		}
+		for _, group := range card.CapGroups {
+			lines = append(lines, fmt.Sprintf("cap_group:%d@%d", group.ID, group.UpdatedAt.UnixNano()))
+		}
+		for _, offer := range card.WelcomeOffers {
+			lines = append(lines, fmt.Sprintf("welcome_offer:%d@%d", offer.ID, offer.UpdatedAt.UnixNano()))
+		}
+		if card.LoyaltyProgram != nil {
+			lines = append(lines, programVersion(card.LoyaltyProgram)...)
+		}
+		for _, category := range card.ExcludedCategories {
+			lines = append(lines, fmt.Sprintf("excluded:%d:%d", card.ID, category.ID))
+		}
+	}
+	return fingerprint(lines)
+}
+
+// programVersion lists a loyalty program's catalog lines, including the
+// transfer partners that value its points.
+func programVersion(program *models.LoyaltyProgram) []string {
+	lines := []string{fmt.Sprintf("program:%d@%d", program.ID, program.UpdatedAt.UnixNano())}
+	for _, partner := range program.TransferPartners {
+		lines = append(lines, fmt.Sprintf("transfer_partner:%d@%d", partner.ID, partner.UpdatedAt.UnixNano()))
+		if partner.ToProgram != nil {
+			lines = append(lines, fmt.Sprintf("program:%d@%d", partner.ToProgram.ID, partner.ToProgram.UpdatedAt.UnixNano()))
+		}
+	}
+	return lines
+}
+
+// fingerprint hashes the lines in sorted order to a short hex string.
+func fingerprint(lines []string) string {
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
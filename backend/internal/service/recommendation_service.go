@@ -1,68 +1,188 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"math"
 	"sort"
+	"strings"
+	"time"
 
+	"gotocard-backend/internal/config"
 	"gotocard-backend/internal/models"
 	"gotocard-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// topRecommendations is how many recommendations are returned overall
+	topRecommendations = 10
+	// defaultTopPerCategory is how many cards are kept for each category
+	defaultTopPerCategory = 5
+	// defaultCategoryPageSize is the page size for per-category listings
+	defaultCategoryPageSize = 10
 )
 
+// engineVersion is recorded on every recommendation run. Bump it whenever a
+// change to the engine can change scores, so run diffs can tell the cause.
+const engineVersion = "1.0.0"
+
+// ErrNotFound is wrapped by errors for users and catalog entries a request
+// names that do not exist.
+var ErrNotFound = errors.New("not found")
+
+// lookupError describes a failed lookup of the named record, wrapping
+// ErrNotFound when the record does not exist.
+func lookupError(what string, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%s %w", what, ErrNotFound)
+	}
+	return fmt.Errorf("failed to get %s: %w", what, err)
+}
+
 type recommendationService struct {
-	repos *repository.Repositories
+	repos     *repository.Repositories
+	retention config.RecommendationConfig
+}
+
+func NewRecommendationService(repos *repository.Repositories, retention config.RecommendationConfig) RecommendationService {
+	return &recommendationService{repos: repos, retention: retention}
+}
+
+func (s *recommendationService) GenerateRecommendations(userID uint, opts models.RecommendationOptions) ([]models.RecommendationResponse, error) {
+	recommendations, run, err := s.rankForUser(userID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save top recommendations to database as a new run
+	err = s.saveRecommendations(run, recommendations, run.Options.PerCategory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save recommendations: %w", err)
+	}
+
+	// Return top 10 recommendations
+	if len(recommendations) > topRecommendations {
+		recommendations = recommendations[:topRecommendations]
+	}
+
+	return recommendations, nil
 }
 
-func NewRecommendationService(repos *repository.Repositories) RecommendationService {
-	return &recommendationService{repos: repos}
+// PreviewRecommendations ranks the catalog for the user exactly as
+// GenerateRecommendations would and returns every recommendation, without
+// saving a run.
+func (s *recommendationService) PreviewRecommendations(userID uint, opts models.RecommendationOptions) ([]models.RecommendationResponse, error) {
+	recommendations, _, err := s.rankForUser(userID, opts)
+	return recommendations, err
 }
 
-func (s *recommendationService) GenerateRecommendations(userID uint) ([]models.RecommendationResponse, error) {
+// rankForUser ranks the catalog against the user's recorded spending and
+// returns the recommendations with the run that would record them.
+func (s *recommendationService) rankForUser(userID uint, opts models.RecommendationOptions) ([]models.RecommendationResponse, *models.RecommendationRun, error) {
+	// Get user eligibility profile
+	user, err := s.repos.User.GetByID(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("user not found: %w", err)
+	}
+
 	// Get user spending data
 	spendings, err := s.repos.UserSpending.GetByUserID(userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user spending: %w", err)
+		return nil, nil, fmt.Errorf("failed to get user spending: %w", err)
+	}
+
+	// Calculate average monthly spending by category over the trailing window
+	asOf := evaluationDate(opts)
+	profile := buildSpendingProfile(spendings, opts.WindowMonths, spendingCutoff(asOf))
+
+	// Use the requested strategy, else the user's preferred one
+	strategy, err := resolveStrategy(user, opts.Strategy)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.Strategy = strategy.Name()
+
+	// Get all active credit cards with the terms in effect on the as-of date
+	cards, err := s.repos.CreditCard.GetActiveCardsAsOf(asOf)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get active cards: %w", err)
 	}
 
-	// Get all active credit cards
-	cards, err := s.repos.CreditCard.GetActiveCards()
+	recommendations, err := s.rankRecommendations(user, cards, profile, strategy, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active cards: %w", err)
+		return nil, nil, err
+	}
+
+	run := &models.RecommendationRun{
+		UserID:         userID,
+		EngineVersion:  engineVersion,
+		SpendingHash:   spendingHash(profile.categories, profile.split()),
+		CatalogVersion: catalogVersion(cards),
+		Options:        opts,
 	}
+	return recommendations, run, nil
+}
 
-	// Calculate spending by category
-	categorySpending := make(map[uint]float64)
-	for _, spending := range spendings {
-		categorySpending[spending.CategoryID] += spending.Amount
+// evaluationDate is the date whose card terms the engine evaluates: the
+// requested as-of date, or now.
+func evaluationDate(opts models.RecommendationOptions) time.Time {
+	if opts.AsOf != nil {
+		if asOf, err := opts.AsOf.Time(); err == nil {
+			return asOf
+		}
 	}
+	return time.Now()
+}
+
+// rankRecommendations runs the engine over a spending profile and returns
+// every (card, category) recommendation the strategy accepts ranked, without
+// persisting anything. Cards the user already holds are not recommended; the
+// rest are valued by what they add to the held cards.
+func (s *recommendationService) rankRecommendations(user *models.User, cards []models.CreditCard, profile spendingProfile, strategy RecommendationStrategy, opts models.RecommendationOptions) ([]models.RecommendationResponse, error) {
+	asOf := evaluationDate(opts)
+	baseline, err := s.currentBaseline(user.ID, profile.categories, profile.split(), asOf)
+	if err != nil {
+		return nil, err
+	}
+	cards = baseline.newCards(cards)
+
+	// Drop cards the user cannot apply for unless asked to flag them instead
+	if !opts.IncludeIneligible {
+		cards = eligibleCards(user.Profile, cards)
+	}
+
+	// Evaluate each card against the whole spending profile
+	evaluations := s.evaluateCards(cards, profile.categories, profile.split())
+	s.addRewardRanges(evaluations, cards, profile)
 
 	// Generate recommendations for each category with spending
 	var recommendations []models.RecommendationResponse
-	for categoryID, totalSpent := range categorySpending {
-		categoryRecs := s.calculateBestCardsForCategory(categoryID, totalSpent, cards)
+	for categoryID := range profile.categories {
+		categoryRecs := s.calculateBestCardsForCategory(categoryID, cards, evaluations, baseline, strategy, opts.Horizon, asOf)
 		recommendations = append(recommendations, categoryRecs...)
 	}
 
-	// Sort by score descending
+	// Flag recommendations for cards the user is not eligible for
+	for i := range recommendations {
+		recommendations[i].IneligibleReason = checkEligibility(user.Profile, recommendations[i].Card)
+		recommendations[i].Eligible = recommendations[i].IneligibleReason == ""
+	}
+
+	// Sort eligible cards first, then by score descending
 	sort.Slice(recommendations, func(i, j int) bool {
+		if recommendations[i].Eligible != recommendations[j].Eligible {
+			return recommendations[i].Eligible
+		}
 		return recommendations[i].Score > recommendations[j].Score
 	})
 
-	// Save top recommendations to database
-	err = s.saveRecommendations(userID, recommendations)
-	if err != nil {
-		return nil, fmt.Errorf("failed to save recommendations: %w", err)
-	}
-
-	// Return top 10 recommendations
-	if len(recommendations) > 10 {
-		recommendations = recommendations[:10]
-	}
-
 	return recommendations, nil
 }
 
-func (s *recommendationService) calculateBestCardsForCategory(categoryID uint, monthlySpent float64, cards []models.CreditCard) []models.RecommendationResponse {
+func (s *recommendationService) calculateBestCardsForCategory(categoryID uint, cards []models.CreditCard, evaluations map[uint]cardEvaluation, baseline walletBaseline, strategy RecommendationStrategy, horizon string, asOf time.Time) []models.RecommendationResponse {
 	var recommendations []models.RecommendationResponse
 
 	category, err := s.repos.Category.GetByID(categoryID)
@@ -71,29 +191,68 @@ func (s *recommendationService) calculateBestCardsForCategory(categoryID uint, m
 	}
 
 	for _, card := range cards {
-		// Find benefits for this category
-		var bestBenefit *models.CardBenefit
-		for _, benefit := range card.CardBenefits {
-			if benefit.CategoryID == categoryID {
-				bestBenefit = &benefit
-				break
-			}
-		}
-
-		if bestBenefit == nil {
+		// Expected reward after card-level rules
+		result, ok := evaluations[card.ID].categories[categoryID]
+		if !ok {
 			continue // No benefits for this category
 		}
-
-		// Calculate expected reward
-		reward := s.calculateReward(monthlySpent, bestBenefit)
+		bestBenefit := result.benefit
+		reward := result.reward
 		
-		// Calculate score (considering annual fee)
+		// Calculate score (considering annual fee) on what the card adds
+		// over the user's current cards
 		annualReward := reward * 12
-		netBenefit := annualReward - card.AnnualFee
-		score := s.calculateScore(netBenefit, card.AnnualFee, bestBenefit)
+		baselineReward := baseline.rewards[categoryID] * 12
+		valuation := valuationFor(card, bestBenefit)
+		breakdown := models.ScoreBreakdown{
+			MonthlySpend:      roundCents(result.spend),
+			GrossReward:       roundCents(result.gross),
+			CappedAmount:      roundCents(math.Max(0, result.gross-reward)),
+			MonthlyReward:     roundCents(reward),
+			AnnualReward:      roundCents(annualReward),
+			MinSpend:          bestBenefit.MinSpend,
+			MinTotalSpend:     card.Rules.MinTotalSpend,
+			MinSpendMet:       result.minSpendMet,
+			AnnualFee:         card.AnnualFee,
+			RewardRate:        effectiveRate(bestBenefit, valuation),
+			BaseRate:          isBaseRate(bestBenefit) && !result.overseasRate,
+			BaselineReward:    roundCents(baselineReward),
+			IncrementalReward: roundCents(incrementalReward(annualReward, baselineReward, baseline.holdsCards())),
+			MerchantReward:    roundCents(result.merchantReward),
+			OverseasSpend:     roundCents(result.overseasSpend),
+			OverseasRate:      result.overseasRate,
+			FXFees:            roundCents(result.fxFees),
+		}
+		if earnsMiles(bestBenefit) {
+			breakdown.AnnualMiles = math.Round(annualReward * 100 / valuation.mileCents)
+		} else if result.transferPartner != nil {
+			breakdown.AnnualMiles = math.Round(result.transferMiles * 12)
+			breakdown.TransferPartner = transferPartnerName(result.transferPartner)
+			breakdown.ConversionFee = roundCents(result.conversionFee)
+		}
+		if spread := result.rewardRange; spread != nil {
+			breakdown.ExpectedReward = roundCents(spread.expected)
+			breakdown.RewardLow = roundCents(spread.low)
+			breakdown.RewardHigh = roundCents(spread.high)
+			breakdown.MinSpendProbability = roundCents(spread.minSpendProbability)
+			breakdown.HistoryMonths = spread.months
+		}
+		if result.spend > 0 {
+			breakdown.MilesPerDollar = roundCents(breakdown.AnnualMiles / (result.spend * 12))
+		}
+		totalSpend := evaluations[card.ID].totalSpend
+		welcome := evaluateWelcomeOffer(card, totalSpend, asOf)
+		if welcome.newToBankOnly && baseline.banks[card.Bank] {
+			welcome.qualified = false
+		}
+		applyHorizon(&breakdown, welcome, expectedFees(card, totalSpend*12), horizon)
+		if !strategy.Accepts(bestBenefit, &breakdown) {
+			continue
+		}
+		score := s.calculateScore(&breakdown, bestBenefit, strategy)
 
 		// Generate reason
-		reason := s.generateReason(bestBenefit, reward, card.AnnualFee)
+		reason := s.generateReason(bestBenefit, &breakdown)
 
 		recommendations = append(recommendations, models.RecommendationResponse{
 			Card:            card,
@@ -101,13 +260,90 @@ func (s *recommendationService) calculateBestCardsForCategory(categoryID uint, m
 			Score:           score,
 			EstimatedReward: reward,
 			Reason:          reason,
+			Breakdown:       breakdown,
+			Strategy:        strategy.Name(),
 		})
 	}
 
 	return recommendations
 }
 
-func (s *recommendationService) calculateReward(monthlySpent float64, benefit *models.CardBenefit) float64 {
+// benefitForCategory returns the card's general benefit for the category,
+// ignoring merchant benefits. When a promotion overlaps the standing
+// benefit, the one that started last wins. Without a specific benefit the
+// card earns its base rate, unless the category is excluded or the card has
+// no base rate, in which case it returns nil.
+func benefitForCategory(card models.CreditCard, categoryID uint) *models.CardBenefit {
+	var benefit *models.CardBenefit
+	for i := range card.CardBenefits {
+		candidate := &card.CardBenefits[i]
+		if candidate.CategoryID == categoryID && len(candidate.Merchants) == 0 && (benefit == nil || startsLater(candidate, benefit)) {
+			benefit = candidate
+		}
+	}
+	if benefit != nil {
+		return benefit
+	}
+
+	for _, category := range card.ExcludedCategories {
+		if category.ID == categoryID {
+			return nil
+		}
+	}
+
+	base := card.BaseRate
+	if base.CashbackRate <= 0 && base.PointsRate <= 0 && base.MilesRate <= 0 {
+		return nil
+	}
+	return &models.CardBenefit{
+		CardID:       card.ID,
+		CategoryID:   categoryID,
+		CashbackRate: base.CashbackRate,
+		PointsRate:   base.PointsRate,
+		MilesRate:    base.MilesRate,
+		Description:  "Base rate on general spend",
+	}
+}
+
+// benefitForMerchant returns the card's benefit for spend at the merchant,
+// or nil if the card has none. Overlapping benefits resolve as in
+// benefitForCategory.
+func benefitForMerchant(card models.CreditCard, merchantID uint) *models.CardBenefit {
+	var benefit *models.CardBenefit
+	for i := range card.CardBenefits {
+		candidate := &card.CardBenefits[i]
+		for _, merchant := range candidate.Merchants {
+			if merchant.ID == merchantID && (benefit == nil || startsLater(candidate, benefit)) {
+				benefit = candidate
+			}
+		}
+	}
+	return benefit
+}
+
+// startsLater reports whether benefit a took effect after benefit b. A
+// benefit without a start date has always been in effect.
+func startsLater(a, b *models.CardBenefit) bool {
+	return a.EffectiveFrom != nil && (b.EffectiveFrom == nil || a.EffectiveFrom.After(*b.EffectiveFrom))
+}
+
+// incrementalReward is what a card adds over the user's held cards. Without
+// held cards nothing is lost by not applying, so the gain is at least zero;
+// against held cards it is negative when the card earns less than them.
+func incrementalReward(annualReward, baselineReward float64, holdsCards bool) float64 {
+	if !holdsCards {
+		return math.Max(0, annualReward-baselineReward)
+	}
+	return annualReward - baselineReward
+}
+
+// isBaseRate reports whether a benefit is the card's base rate fallback
+// rather than one of its category benefits.
+func isBaseRate(benefit *models.CardBenefit) bool {
+	return benefit.ID == 0
+}
+
+func (s *recommendationService) calculateReward(monthlySpent float64, benefit *models.CardBenefit, valuation rewardValuation) float64 {
 	if monthlySpent < benefit.MinSpend {
 		return 0
 	}
@@ -117,51 +353,196 @@ func (s *recommendationService) calculateReward(monthlySpent float64, benefit *m
 		spentAmount = benefit.Cap
 	}
 
-	// Calculate based on best rate available
-	if benefit.CashbackRate > 0 {
-		return spentAmount * (benefit.CashbackRate / 100)
+	if len(benefit.Tiers) > 0 {
+		return s.calculateTieredReward(monthlySpent, spentAmount, benefit, valuation)
 	}
-	
-	if benefit.PointsRate > 0 {
-		// Assume 1 point = $0.01 (can be adjusted)
-		return spentAmount * (benefit.PointsRate / 100) * 0.01
+
+	return rewardValue(spentAmount, benefit.CashbackRate, benefit.PointsRate, benefit.MilesRate, valuation)
+}
+
+// calculateTieredReward applies a benefit's tier schedule to the monthly
+// spend. The tier reached depends on the whole monthly spend, while only
+// spentAmount, the spend under the benefit's cap, earns the reward. Tiers
+// are expected in ascending MinSpend order.
+func (s *recommendationService) calculateTieredReward(monthlySpent, spentAmount float64, benefit *models.CardBenefit, valuation rewardValuation) float64 {
+	if benefit.TierMode == models.TierModeMarginal {
+		var reward float64
+		for i, tier := range benefit.Tiers {
+			if spentAmount <= tier.MinSpend {
+				break
+			}
+
+			bandSpend := spentAmount - tier.MinSpend
+			if i+1 < len(benefit.Tiers) {
+				bandSpend = math.Min(bandSpend, benefit.Tiers[i+1].MinSpend-tier.MinSpend)
+			}
+			if tier.Cap > 0 {
+				bandSpend = math.Min(bandSpend, tier.Cap)
+			}
+
+			reward += rewardValue(bandSpend, tier.CashbackRate, tier.PointsRate, tier.MilesRate, valuation)
+		}
+		return reward
 	}
-	
-	if benefit.MilesRate > 0 {
-		// Assume 1 mile = $0.015 (can be adjusted)
-		return spentAmount * (benefit.MilesRate / 100) * 0.015
+
+	// Threshold mode: the highest tier reached applies to the whole spend
+	var reached *models.BenefitTier
+	for i := range benefit.Tiers {
+		if monthlySpent >= benefit.Tiers[i].MinSpend {
+			reached = &benefit.Tiers[i]
+		}
+	}
+	if reached == nil {
+		return 0
+	}
+
+	if reached.Cap > 0 {
+		spentAmount = math.Min(spentAmount, reached.Cap)
+	}
+	return rewardValue(spentAmount, reached.CashbackRate, reached.PointsRate, reached.MilesRate, valuation)
+}
+
+// rewardValue converts spend at the given rates into a dollar reward, using
+// the best rate available. Cashback rates are percentages; points and miles
+// rates are units earned per $100 of spend, the unit rates are stored in.
+func rewardValue(spentAmount, cashbackRate, pointsRate, milesRate float64, valuation rewardValuation) float64 {
+	if cashbackRate > 0 {
+		return spentAmount * (cashbackRate / 100)
+	}
+
+	if pointsRate > 0 {
+		points := spentAmount * pointsRate / 100
+		return points * valuation.pointCents / 100
+	}
+
+	if milesRate > 0 {
+		miles := spentAmount * milesRate / 100
+		return miles * valuation.mileCents / 100
 	}
 
 	return 0
 }
 
-func (s *recommendationService) calculateScore(netBenefit, annualFee float64, benefit *models.CardBenefit) float64 {
-	// Base score from net benefit
-	score := netBenefit
+// effectiveRate is the benefit's headline rate as a percentage of spend
+// returned in dollars, so points and miles compare directly with cashback.
+func effectiveRate(benefit *models.CardBenefit, valuation rewardValuation) float64 {
+	return rewardValue(100, benefit.CashbackRate, benefit.PointsRate, benefit.MilesRate, valuation)
+}
+
+// Fallback valuations for cards without a loyalty program
+const (
+	defaultPointCents = 1.0
+	defaultMileCents  = 1.5
+)
+
+// rewardValuation is the dollar value, in cents, of one point and one mile.
+// When points are worth more transferred to an airline, pointCents is their
+// value through transfer, before conversion fees.
+type rewardValuation struct {
+	pointCents       float64
+	mileCents        float64
+	directPointCents float64
+	transfer         *models.TransferPartner
+}
 
-	// Bonus for higher reward rates
-	if benefit.CashbackRate > 0 {
-		score += benefit.CashbackRate * 10
+// valuationFor resolves point and mile values from the card's loyalty
+// program, overridden by the benefit's own program when it has one.
+func valuationFor(card models.CreditCard, benefit *models.CardBenefit) rewardValuation {
+	valuation := rewardValuation{pointCents: defaultPointCents, mileCents: defaultMileCents, directPointCents: defaultPointCents}
+	valuation.apply(card.LoyaltyProgram)
+	if benefit != nil {
+		valuation.apply(benefit.LoyaltyProgram)
 	}
-	if benefit.PointsRate > 0 {
-		score += benefit.PointsRate * 8
+	return valuation
+}
+
+func (v *rewardValuation) apply(program *models.LoyaltyProgram) {
+	if program == nil || program.CentsPerUnit <= 0 {
+		return
 	}
-	if benefit.MilesRate > 0 {
-		score += benefit.MilesRate * 12
+
+	switch program.Type {
+	case models.LoyaltyTypePoints:
+		v.pointCents = program.CentsPerUnit
+		v.directPointCents = program.CentsPerUnit
+		v.transfer = nil
+		if partner := bestTransferPartner(program); partner != nil && transferCents(partner) > v.pointCents {
+			v.pointCents = transferCents(partner)
+			v.transfer = partner
+		}
+	case models.LoyaltyTypeMiles:
+		v.mileCents = program.CentsPerUnit
 	}
+}
 
-	// Penalty for annual fee
-	score -= annualFee * 0.5
+// calculateScore scores a recommendation from its breakdown using the
+// strategy's score components and records the components on it.
+func (s *recommendationService) calculateScore(breakdown *models.ScoreBreakdown, benefit *models.CardBenefit, strategy RecommendationStrategy) float64 {
+	breakdown.Components = strategy.Components(benefit, breakdown)
+
+	var score float64
+	for _, component := range breakdown.Components {
+		score += component.Value
+	}
+	breakdown.RawScore = roundCents(score)
 
 	// Normalize score to 0-100 range
 	score = math.Max(0, math.Min(100, score))
 	
-	return math.Round(score*100) / 100
+	breakdown.FinalScore = roundCents(score)
+	return breakdown.FinalScore
 }
 
-func (s *recommendationService) generateReason(benefit *models.CardBenefit, monthlyReward, annualFee float64) string {
-	annualReward := monthlyReward * 12
-	netBenefit := annualReward - annualFee
+// applyHorizon fills in the ongoing, first-year and amortized values of the
+// incremental reward net of the expected fee for each, and sets the net
+// benefit used for scoring and the fee waiver to the requested horizon's
+// figures.
+func applyHorizon(breakdown *models.ScoreBreakdown, welcome welcomeValue, fees horizonFees, horizon string) {
+	if horizon == "" {
+		horizon = models.HorizonOngoing
+	}
+
+	var welcomeBonus float64
+	if welcome.qualified {
+		welcomeBonus = welcome.value
+	}
+
+	breakdown.WelcomeBonus = roundCents(welcome.value)
+	breakdown.WelcomeBonusQualified = welcome.qualified
+	breakdown.WelcomeNewToBankOnly = welcome.newToBankOnly
+	breakdown.OngoingValue = roundCents(breakdown.IncrementalReward - fees.ongoing)
+	breakdown.FirstYearValue = roundCents(breakdown.IncrementalReward - fees.firstYear + welcomeBonus)
+	breakdown.AmortizedValue = roundCents(breakdown.IncrementalReward - fees.amortized + welcomeBonus/welcomeAmortizationYears)
+	breakdown.FeeWaiver = roundCents(breakdown.AnnualFee - fees.forHorizon(horizon))
+	breakdown.Horizon = horizon
+
+	switch horizon {
+	case models.HorizonFirstYear:
+		breakdown.NetBenefit = breakdown.FirstYearValue
+	case models.HorizonAmortized:
+		breakdown.NetBenefit = breakdown.AmortizedValue
+	default:
+		breakdown.NetBenefit = breakdown.OngoingValue
+	}
+}
+
+// merchantNames lists merchant names for display.
+func merchantNames(merchants []models.Merchant) string {
+	names := make([]string, len(merchants))
+	for i, merchant := range merchants {
+		names[i] = merchant.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func (s *recommendationService) generateReason(benefit *models.CardBenefit, breakdown *models.ScoreBreakdown) string {
+	monthlyReward := breakdown.MonthlyReward
+	annualFee := breakdown.AnnualFee
+	netBenefit := breakdown.NetBenefit
 
 	reason := fmt.Sprintf("Earn %.2f%% ", benefit.CashbackRate)
 	if benefit.PointsRate > 0 {
@@ -171,10 +552,50 @@ func (s *recommendationService) generateReason(benefit *models.CardBenefit, mont
 		reason = fmt.Sprintf("Earn %.1fx miles ", benefit.MilesRate)
 	}
 
-	reason += fmt.Sprintf("on this category. Expected monthly reward: $%.2f", monthlyReward)
+	if breakdown.BaseRate {
+		reason += "as the card's base rate "
+	}
+	if breakdown.OverseasRate {
+		reason += "as the card's overseas rate "
+	}
+	if len(benefit.Merchants) > 0 {
+		reason += fmt.Sprintf("at %s", merchantNames(benefit.Merchants))
+	} else {
+		reason += "on this category"
+	}
+	reason += fmt.Sprintf(". Expected monthly reward: $%.2f", monthlyReward)
+	if breakdown.FXFees > 0 {
+		reason += fmt.Sprintf(" after $%.2f in FX fees on $%.2f of overseas spend", breakdown.FXFees, breakdown.OverseasSpend)
+	}
+	if breakdown.MerchantReward > 0 && breakdown.MerchantReward < monthlyReward {
+		reason += fmt.Sprintf(" ($%.2f from merchant offers)", breakdown.MerchantReward)
+	}
+	if breakdown.HistoryMonths > 1 && breakdown.RewardHigh > breakdown.RewardLow {
+		reason += fmt.Sprintf(", typically $%.2f to $%.2f", breakdown.RewardLow, breakdown.RewardHigh)
+	}
+	if breakdown.HistoryMonths > 1 && (breakdown.MinSpend > 0 || breakdown.MinTotalSpend > 0) {
+		reason += fmt.Sprintf(", minimum spend met in %.0f%% of months", breakdown.MinSpendProbability*100)
+	}
+	if breakdown.TransferPartner != "" {
+		reason += fmt.Sprintf(", %.2f miles per dollar converted to %s", breakdown.MilesPerDollar, breakdown.TransferPartner)
+		if breakdown.ConversionFee > 0 {
+			reason += fmt.Sprintf(" after $%.2f a year in conversion fees", breakdown.ConversionFee)
+		}
+	}
+
+	if breakdown.BaselineReward != 0 {
+		if breakdown.IncrementalReward >= 0 {
+			reason += fmt.Sprintf(", $%.2f a year more than your current cards", breakdown.IncrementalReward)
+		} else {
+			reason += fmt.Sprintf(", $%.2f a year less than your current cards", -breakdown.IncrementalReward)
+		}
+	}
 	
 	if annualFee > 0 {
 		reason += fmt.Sprintf(", Annual fee: $%.0f", annualFee)
+		if breakdown.FeeWaiver > 0 {
+			reason += fmt.Sprintf(" (expected $%.0f after waivers)", annualFee-breakdown.FeeWaiver)
+		}
 	}
 	
 	reason += fmt.Sprintf(", Net annual benefit: $%.2f", netBenefit)
@@ -182,81 +603,93 @@ func (s *recommendationService) generateReason(benefit *models.CardBenefit, mont
 	return reason
 }
 
-func (s *recommendationService) saveRecommendations(userID uint, recommendations []models.RecommendationResponse) error {
-	// Clear existing recommendations
-	err := s.repos.Recommendation.DeleteByUserID(userID)
-	if err != nil {
-		return err
+// saveRecommendations stores the overall top 10 recommendations and the top
+// perCategory cards of every category as a new run, then prunes the user's
+// older runs according to the retention settings.
+func (s *recommendationService) saveRecommendations(run *models.RecommendationRun, recommendations []models.RecommendationResponse, perCategory int) error {
+	if perCategory <= 0 {
+		perCategory = defaultTopPerCategory
 	}
 
-	// Save new recommendations (top 10)
-	count := len(recommendations)
-	if count > 10 {
-		count = 10
+	// Recommendations are ranked, so the first ones seen are each category's best
+	kept := make(map[uint]int)
+	for i := range recommendations {
+		categoryID := recommendations[i].Category.ID
+		if i >= topRecommendations && kept[categoryID] >= perCategory {
+			continue
+		}
+		kept[categoryID]++
+
+		run.Recommendations = append(run.Recommendations, models.Recommendation{
+			UserID:           run.UserID,
+			CategoryID:       recommendations[i].Category.ID,
+			CardID:           recommendations[i].Card.ID,
+			Score:            recommendations[i].Score,
+			EstimatedReward:  recommendations[i].EstimatedReward,
+			Reason:           recommendations[i].Reason,
+			Breakdown:        recommendations[i].Breakdown,
+			Strategy:         recommendations[i].Strategy,
+			Eligible:         recommendations[i].Eligible,
+			IneligibleReason: recommendations[i].IneligibleReason,
+		})
 	}
 
-	for i := 0; i < count; i++ {
-		rec := &models.Recommendation{
-			UserID:          userID,
-			CategoryID:      recommendations[i].Category.ID,
-			CardID:          recommendations[i].Card.ID,
-			Score:           recommendations[i].Score,
-			EstimatedReward: recommendations[i].EstimatedReward,
-			Reason:          recommendations[i].Reason,
-		}
-		
-		err := s.repos.Recommendation.Create(rec)
-		if err != nil {
-			return err
-		}
+	if err := s.repos.RecommendationRun.Create(run); err != nil {
+		return err
+	}
+
+	// A failed prune leaves extra history behind but must not fail the run
+	if err := s.pruneRecommendationRuns(run.UserID); err != nil {
+		log.Printf("Failed to prune recommendation runs for user %d: %v", run.UserID, err)
 	}
 
 	return nil
 }
 
+// GetRecommendationsByUser returns the overall top 10 of the user's latest run.
 func (s *recommendationService) GetRecommendationsByUser(userID uint) ([]models.RecommendationResponse, error) {
 	recs, err := s.repos.Recommendation.GetByUserID(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	var responses []models.RecommendationResponse
-	for _, rec := range recs {
-		responses = append(responses, models.RecommendationResponse{
-			ID:              rec.ID,
-			Card:            rec.Card,
-			Category:        rec.Category,
-			Score:           rec.Score,
-			EstimatedReward: rec.EstimatedReward,
-			Reason:          rec.Reason,
-		})
+	if len(recs) > topRecommendations {
+		recs = recs[:topRecommendations]
 	}
 
-	return responses, nil
+	return recommendationResponses(recs), nil
 }
 
-func (s *recommendationService) GetRecommendationsByCategory(userID, categoryID uint) ([]models.RecommendationResponse, error) {
-	recs, err := s.repos.Recommendation.GetByUserAndCategory(userID, categoryID)
+// GetRecommendationsByCategory pages through the cards kept for one category
+// in the user's latest run, best first.
+func (s *recommendationService) GetRecommendationsByCategory(userID, categoryID uint, page models.PageOptions) (*models.CategoryRecommendationsResponse, error) {
+	category, err := s.repos.Category.GetByID(categoryID)
 	if err != nil {
-		return nil, err
+		return nil, lookupError("category", err)
 	}
 
-	var responses []models.RecommendationResponse
-	for _, rec := range recs {
-		responses = append(responses, models.RecommendationResponse{
-			ID:              rec.ID,
-			Card:            rec.Card,
-			Category:        rec.Category,
-			Score:           rec.Score,
-			EstimatedReward: rec.EstimatedReward,
-			Reason:          rec.Reason,
-		})
+	if page.Page <= 0 {
+		page.Page = 1
+	}
+	if page.PageSize <= 0 {
+		page.PageSize = defaultCategoryPageSize
+	}
+
+	recs, total, err := s.repos.Recommendation.GetByUserAndCategory(userID, categoryID, (page.Page-1)*page.PageSize, page.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category recommendations: %w", err)
 	}
 
-	return responses, nil
+	return &models.CategoryRecommendationsResponse{
+		Category:        *category,
+		Recommendations: recommendationResponses(recs),
+		Page:            page.Page,
+		PageSize:        page.PageSize,
+		Total:           total,
+	}, nil
 }
 
 func (s *recommendationService) RefreshRecommendations(userID uint) error {
-	_, err := s.GenerateRecommendations(userID)
+	_, err := s.GenerateRecommendations(userID, models.RecommendationOptions{})
 	return err
 } 
\ No newline at end of file
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"math/rand"
+	"sort"
+
+	"gotocard-backend/internal/models"
+)
+
+// rewardRangeTrials is the number of simulated months drawn from the
+// spending history when estimating reward ranges.
+const rewardRangeTrials = 200
+
+// rewardRange describes how a card's monthly reward in a category varies
+// with the user's month-to-month spending.
+type rewardRange struct {
+	expected            float64 // mean monthly reward across simulated months
+	low                 float64 // 10th percentile
+	high                float64 // 90th percentile
+	minSpendProbability float64 // share of simulated months meeting min spend
+	months              int     // recorded months the simulation drew from
+}
+
+// simulatedMonths draws monthly spending profiles from the history. Each
+// category's spend is taken from an independently chosen recorded month, so
+// the draws mix quiet and busy months the way caps and minimum spend
+// thresholds will actually see them. A fixed seed keeps rankings stable
+// between runs.
+func simulatedMonths(history []map[uint]float64) []map[uint]float64 {
+	categories := make(map[uint]bool)
+	for _, month := range history {
+		for categoryID := range month {
+			categories[categoryID] = true
+		}
+	}
+	ids := make([]uint, 0, len(categories))
+	for categoryID := range categories {
+		ids = append(ids, categoryID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	rng := rand.New(rand.NewSource(1))
+	trials := make([]map[uint]float64, rewardRangeTrials)
+	for i := range trials {
+		trial := make(map[uint]float64, len(ids))
+		for _, categoryID := range ids {
+			if spent := history[rng.Intn(len(history))][categoryID]; spent > 0 {
+				trial[categoryID] = spent
+			}
+		}
+		trials[i] = trial
+	}
+	return trials
+}
+
+// addRewardRanges evaluates every card against months simulated from the
+// spending history and records the spread of each category's reward on its
+// evaluation. Without recorded history the evaluations are left unchanged.
+func (s *recommendationService) addRewardRanges(evaluations map[uint]cardEvaluation, cards []models.CreditCard, profile spendingProfile) {
+	if len(profile.history) == 0 {
+		return
+	}
+	trials := simulatedMonths(profile.history)
+
+	for _, card := range cards {
+		evaluation, ok := evaluations[card.ID]
+		if !ok {
+			continue
+		}
+
+		rewards := make(map[uint][]float64, len(evaluation.categories))
+		minSpendMet := make(map[uint]int, len(evaluation.categories))
+		for _, trial := range trials {
+			result := s.evaluateCard(card, trial, profile.split())
+			for categoryID := range evaluation.categories {
+				monthly := result.categories[categoryID]
+				rewards[categoryID] = append(rewards[categoryID], monthly.reward)
+				if monthly.minSpendMet {
+					minSpendMet[categoryID]++
+				}
+			}
+		}
+
+		for categoryID, result := range evaluation.categories {
+			samples := rewards[categoryID]
+			sort.Float64s(samples)
+			var total float64
+			for _, reward := range samples {
+				total += reward
+			}
+			result.rewardRange = &rewardRange{
+				expected:            total / float64(len(samples)),
+				low:                 percentile(samples, 0.1),
+				high:                percentile(samples, 0.9),
+				minSpendProbability: float64(minSpendMet[categoryID]) / float64(len(samples)),
+				months:              len(profile.history),
+			}
+			evaluation.categories[categoryID] = result
+		}
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted samples.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"gotocard-backend/internal/models"
@@ -17,12 +18,17 @@ import (
 )
 
 type scrapingService struct {
-	repos     *repository.Repositories
-	client    *http.Client
-	collector *colly.Collector
+	repos         *repository.Repositories
+	notifications NotificationService
+	client        *http.Client
+	collector     *colly.Collector
+
+	// Cards added since the last notification pass
+	mu           sync.Mutex
+	changedCards []uint
 }
 
-func NewScrapingService(repos *repository.Repositories) ScrapingService {
+func NewScrapingService(repos *repository.Repositories, notifications NotificationService) ScrapingService {
 	// Create a new collector with proper configuration
 	c := colly.NewCollector(
 		colly.Debugger(&debug.LogDebugger{}),
@@ -40,8 +46,9 @@ func NewScrapingService(repos *repository.Repositories) ScrapingService {
 	c.SetRequestTimeout(30 * time.Second)
 
 	return &scrapingService{
-		repos:     repos,
-		collector: c,
+		repos:         repos,
+		notifications: notifications,
+		collector:     c,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -72,6 +79,7 @@ func (s *scrapingService) ScrapeCardData() error {
 	}
 
 	log.Println("Credit card data scraping completed")
+	s.notifyChangedCards()
 	return nil
 }
 
@@ -81,6 +89,7 @@ func (s *scrapingService) UpdateCardDatabase() error {
 
 func (s *scrapingService) ScrapeCardDataBySource(source string) error {
 	log.Printf("Starting scraping from specific source: %s", source)
+	defer s.notifyChangedCards()
 
 	switch strings.ToLower(source) {
 	case "singsaver":
@@ -92,6 +101,33 @@ func (s *scrapingService) ScrapeCardDataBySource(source string) error {
 	}
 }
 
+// recordChangedCard remembers a card added to the catalog, or one whose
+// terms changed, so users can be notified once the scrape finishes.
+func (s *scrapingService) recordChangedCard(cardID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changedCards = append(s.changedCards, cardID)
+}
+
+// notifyChangedCards hands the cards added by a scrape to the notification
+// service in the background, so the scrape request does not wait for every
+// user to be re-evaluated.
+func (s *scrapingService) notifyChangedCards() {
+	s.mu.Lock()
+	cardIDs := s.changedCards
+	s.changedCards = nil
+	s.mu.Unlock()
+
+	if len(cardIDs) == 0 || s.notifications == nil {
+		return
+	}
+	go func() {
+		if err := s.notifications.NotifyCatalogChange(cardIDs); err != nil {
+			log.Printf("Failed to notify users of catalog changes: %v", err)
+		}
+	}()
+}
+
 // Scrape credit card data from SingSaver
 func (s *scrapingService) scrapeSingSaver() error {
 	log.Println("Scraping SingSaver credit cards...")
@@ -147,6 +183,16 @@ func (s *scrapingService) scrapeSingSaver() error {
 		incomeText := e.ChildText(".income-requirement, .eligibility")
 		card.MinIncome = s.parseIncomeRequirement(incomeText)
 
+		// Extract the cashback rate on general spend, when listed
+		if cashbackText := e.ChildText(".cashback-rate, .cashback"); cashbackText != "" {
+			card.BaseRate.CashbackRate = s.parseCashbackRate(cashbackText)
+		}
+
+		// Extract the foreign currency transaction fee, when listed
+		if fxText := e.ChildText(".fx-fee, .foreign-currency-fee"); fxText != "" {
+			card.Overseas.FXFeeRate = s.parseFXFeeRate(fxText)
+		}
+
 		// Only add if we have a name
 		if card.Name != "" {
 			log.Printf("Found SingSaver card: %s", card.Name)
@@ -249,11 +295,10 @@ func (s *scrapingService) scrapeMoneySmart() error {
 
 		if cardName != "" {
 			card := models.CreditCard{
-				Name:      cardName,
-				Bank:      s.extractBankName(cardName),
-				CardType:  s.determineCardType(cardName),
-				MinIncome: 30000, // Default
-				IsActive:  true,
+				Name:     cardName,
+				Bank:     s.extractBankName(cardName),
+				CardType: s.determineCardType(cardName),
+				IsActive: true,
 			}
 
 			// Try to extract annual fee
@@ -286,11 +331,10 @@ func (s *scrapingService) scrapeMoneySmart() error {
 				log.Printf("Found card pattern in page text: %s", pattern)
 				// Try to create a card from this pattern
 				card := models.CreditCard{
-					Name:      pattern,
-					Bank:      s.extractBankName(pattern),
-					CardType:  s.determineCardType(pattern),
-					MinIncome: 30000,
-					IsActive:  true,
+					Name:     pattern,
+					Bank:     s.extractBankName(pattern),
+					CardType: s.determineCardType(pattern),
+					IsActive: true,
 				}
 
 				// Avoid duplicates
@@ -339,58 +383,6 @@ func (s *scrapingService) scrapeMoneySmart() error {
 	return nil
 }
 
-// Process scraped cards and save to database
-func (s *scrapingService) processScrapedCards(cards []ScrapedCard) error {
-	categories, err := s.repos.Category.List()
-	if err != nil {
-		return fmt.Errorf("failed to get categories: %w", err)
-	}
-
-	successCount := 0
-	for _, cardData := range cards {
-		if cardData.Name == "" {
-			continue
-		}
-
-		// Check if card already exists
-		existingCard, err := s.repos.CreditCard.GetByBankAndName(cardData.Bank, cardData.Name)
-		if err == nil && existingCard != nil {
-			log.Printf("Card already exists: %s", cardData.Name)
-			continue
-		}
-
-		// Create new card
-		card := &models.CreditCard{
-			Name:        cardData.Name,
-			Bank:        cardData.Bank,
-			CardType:    s.determineCardType(cardData.Name),
-			AnnualFee:   cardData.AnnualFee,
-			Description: cardData.Description,
-			MinIncome:   cardData.MinIncome,
-			IsActive:    true,
-		}
-
-		// Set default minimum income if not provided
-		if card.MinIncome == 0 {
-			card.MinIncome = 30000 // Default S$30,000
-		}
-
-		err = s.repos.CreditCard.Create(card)
-		if err != nil {
-			log.Printf("Failed to create card %s: %v", cardData.Name, err)
-			continue
-		}
-
-		// Add realistic card benefits
-		s.addRealisticCardBenefits(card.ID, cardData, categories)
-		successCount++
-		log.Printf("Successfully added card: %s from %s", cardData.Name, cardData.Source)
-	}
-
-	log.Printf("Processed %d cards, successfully added %d new cards", len(cards), successCount)
-	return nil
-}
-
 // Add realistic card benefits based on card type and bank
 func (s *scrapingService) addRealisticCardBenefits(cardID uint, cardData ScrapedCard, categories []models.Category) {
 	// Define realistic benefit patterns for Singapore credit cards
@@ -420,15 +412,42 @@ func (s *scrapingService) addRealisticCardBenefits(cardID uint, cardData Scraped
 	}
 }
 
+// defaultBaseCashbackRate is the rate, in percent, assumed on general spend
+// when the scrape found none
+const defaultBaseCashbackRate = 1.0
+
+// baseCashbackRate is the card's earn rate on general spend
+func (s *scrapingService) baseCashbackRate(cardData ScrapedCard) float64 {
+	if cardData.CashbackRate > 0 {
+		return cardData.CashbackRate
+	}
+	return defaultBaseCashbackRate
+}
+
+// applyBaseEarnRate sets the card's base rate for categories without a
+// specific benefit from the scraped cashback rate, or the default rate when
+// none was scraped.
+func (s *scrapingService) applyBaseEarnRate(card *models.CreditCard, cardData ScrapedCard) {
+	card.BaseRate.CashbackRate = s.baseCashbackRate(cardData)
+}
+
+// applyOverseasTerms sets the card's FX fee and overseas earn rate from the
+// scraped terms. Terms the scrape did not find are left unset.
+func (s *scrapingService) applyOverseasTerms(card *models.CreditCard, cardData ScrapedCard) {
+	if cardData.FXFeeRate > 0 {
+		card.Overseas.FXFeeRate = cardData.FXFeeRate
+	}
+	if cardData.OverseasPointsRate > 0 {
+		card.Overseas.PointsRate = cardData.OverseasPointsRate
+	}
+}
+
 // Get benefit patterns based on card characteristics
 func (s *scrapingService) getBenefitPatterns(cardData ScrapedCard) map[string]BenefitPattern {
 	patterns := make(map[string]BenefitPattern)
 
 	// Default patterns
-	baseRate := 1.0
-	if cardData.CashbackRate > 0 {
-		baseRate = cardData.CashbackRate
-	}
+	baseRate := s.baseCashbackRate(cardData)
 
 	// Adjust patterns based on card type and bank
 	switch {
@@ -464,26 +483,22 @@ func (s *scrapingService) getBenefitPatterns(cardData ScrapedCard) map[string]Be
 		patterns["Petrol"] = BenefitPattern{Rate: 8.0, Cap: 1000, MinSpend: 0}
 	}
 
-	// Ensure all categories have at least a base rate
-	allCategories := []string{"Dining", "Groceries", "Petrol", "Shopping", "Transport", "Travel", "Entertainment", "Healthcare", "Bills", "Online"}
-	for _, category := range allCategories {
-		if _, exists := patterns[category]; !exists {
-			patterns[category] = BenefitPattern{Rate: baseRate, Cap: 1000, MinSpend: 0}
-		}
-	}
+	// Other categories earn the card's base rate, see applyBaseEarnRate
 
 	return patterns
 }
 
 // Helper types and functions
 type ScrapedCard struct {
-	Name         string
-	Bank         string
-	AnnualFee    float64
-	CashbackRate float64
-	MinIncome    float64
-	Description  string
-	Source       string
+	Name               string
+	Bank               string
+	AnnualFee          float64
+	CashbackRate       float64
+	FXFeeRate          float64
+	OverseasPointsRate float64
+	MinIncome          float64
+	Description        string
+	Source             string
 }
 
 type BenefitPattern struct {
@@ -562,6 +577,22 @@ func (s *scrapingService) parseCashbackRate(cashbackText string) float64 {
 	return 1.0 // Default 1%
 }
 
+// parseFXFeeRate reads a fee such as "3.25% on foreign currency spend". It
+// returns 0 when the text holds no percentage.
+func (s *scrapingService) parseFXFeeRate(feeText string) float64 {
+	re := regexp.MustCompile(`(\d+\.?\d*)\s*%`)
+	matches := re.FindStringSubmatch(feeText)
+
+	if len(matches) > 1 {
+		rate, err := strconv.ParseFloat(matches[1], 64)
+		if err == nil && rate <= 10 { // Reasonable FX fee
+			return rate
+		}
+	}
+
+	return 0
+}
+
 func (s *scrapingService) parseMinIncome(incomeText string) float64 {
 	// Extract income from text like "Minimum income: S$30,000"
 	incomeText = strings.ToLower(strings.TrimSpace(incomeText))
@@ -610,22 +641,50 @@ func (s *scrapingService) extractBankFromCardName(cardName string) string {
 }
 
 func (s *scrapingService) processAndSaveCard(card models.CreditCard, source string) error {
-	// Check if card already exists
-	existingCard, err := s.repos.CreditCard.GetByBankAndName(card.Bank, card.Name)
-	if err == nil && existingCard != nil {
-		log.Printf("Card already exists: %s", card.Name)
-		return nil
-	}
-
 	// Set source information
 	card.SourceURL = source
 	card.IsActive = true
 
+	// Link the bank's rewards currency so points are valued correctly
+	if program := s.loyaltyProgramForBank(card.Bank); program != nil {
+		card.LoyaltyProgramID = &program.ID
+	}
+
+	cardData := ScrapedCard{
+		Name:               card.Name,
+		Bank:               card.Bank,
+		AnnualFee:          card.AnnualFee,
+		CashbackRate:       card.BaseRate.CashbackRate,
+		FXFeeRate:          card.Overseas.FXFeeRate,
+		OverseasPointsRate: card.Overseas.PointsRate,
+		Source:             source,
+	}
+	s.applyOverseasTerms(&card, cardData)
+
+	// Check if card already exists. Only scraped terms are compared, so the
+	// defaults below never overwrite what is stored.
+	existingCard, err := s.repos.CreditCard.GetByBankAndName(card.Bank, card.Name)
+	if err == nil && existingCard != nil {
+		return s.updateScrapedCard(existingCard, &card)
+	}
+
 	// Set default minimum income if not provided
 	if card.MinIncome == 0 {
 		card.MinIncome = 30000 // Default S$30,000
 	}
 
+	// Most Singapore cards waive the first year's fee
+	if card.AnnualFee > 0 && card.FeeWaiver.FirstYearsWaived == 0 {
+		card.FeeWaiver.FirstYearsWaived = 1
+	}
+
+	s.applyBaseEarnRate(&card, cardData)
+
+	categories, err := s.repos.Category.List()
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+
 	err = s.repos.CreditCard.Create(&card)
 	if err != nil {
 		log.Printf("Failed to create card %s: %v", card.Name, err)
@@ -633,21 +692,95 @@ func (s *scrapingService) processAndSaveCard(card models.CreditCard, source stri
 	}
 
 	// Add realistic card benefits
-	categories, err := s.repos.Category.List()
-	if err != nil {
-		return fmt.Errorf("failed to get categories: %w", err)
-	}
-	s.addRealisticCardBenefits(card.ID, ScrapedCard{
-		Name:      card.Name,
-		Bank:      card.Bank,
-		AnnualFee: card.AnnualFee,
-		Source:    source,
-	}, categories)
+	s.addRealisticCardBenefits(card.ID, cardData, categories)
+	s.recordChangedCard(card.ID)
 
 	log.Printf("Successfully added card: %s from %s", card.Name, source)
 	return nil
 }
 
+// updateScrapedCard refreshes a catalog card with freshly scraped terms.
+// Only terms the scrape found are copied, and a card whose fee or earn
+// rates changed is recorded so users are notified, as for a new card.
+func (s *scrapingService) updateScrapedCard(existing, scraped *models.CreditCard) error {
+	updated := *existing
+	if scraped.AnnualFee > 0 {
+		updated.AnnualFee = scraped.AnnualFee
+	}
+	if scraped.MinIncome > 0 {
+		updated.MinIncome = scraped.MinIncome
+	}
+	if scraped.Description != "" {
+		updated.Description = scraped.Description
+	}
+	if scraped.LoyaltyProgramID != nil {
+		updated.LoyaltyProgramID = scraped.LoyaltyProgramID
+	}
+	if scraped.BaseRate.CashbackRate > 0 {
+		updated.BaseRate.CashbackRate = scraped.BaseRate.CashbackRate
+	}
+	if scraped.Overseas.FXFeeRate > 0 {
+		updated.Overseas.FXFeeRate = scraped.Overseas.FXFeeRate
+	}
+	if scraped.Overseas.PointsRate > 0 {
+		updated.Overseas.PointsRate = scraped.Overseas.PointsRate
+	}
+
+	termsChanged := updated.AnnualFee != existing.AnnualFee ||
+		updated.BaseRate != existing.BaseRate ||
+		updated.Overseas != existing.Overseas
+	if !termsChanged && updated.MinIncome == existing.MinIncome &&
+		updated.Description == existing.Description &&
+		equalIDs(updated.LoyaltyProgramID, existing.LoyaltyProgramID) {
+		log.Printf("Card already up to date: %s", existing.Name)
+		return nil
+	}
+
+	// Save would also write the preloaded relationships back
+	updated.CardBenefits = nil
+	updated.CapGroups = nil
+	updated.LoyaltyProgram = nil
+	updated.WelcomeOffers = nil
+	updated.ExcludedCategories = nil
+	if err := s.repos.CreditCard.Update(&updated); err != nil {
+		return fmt.Errorf("failed to update card %s: %w", existing.Name, err)
+	}
+	if termsChanged {
+		s.recordChangedCard(existing.ID)
+	}
+
+	log.Printf("Successfully updated card: %s", existing.Name)
+	return nil
+}
+
+func equalIDs(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (s *scrapingService) loyaltyProgramForBank(bank string) *models.LoyaltyProgram {
+	programs := map[string]string{
+		"DBS":      "DBS Points",
+		"UOB":      "UNI$",
+		"OCBC":     "OCBC$",
+		"Citibank": "Citi ThankYou Points",
+		"HSBC":     "HSBC Reward Points",
+	}
+
+	name, exists := programs[bank]
+	if !exists {
+		return nil
+	}
+
+	program, err := s.repos.LoyaltyProgram.GetByName(name)
+	if err != nil {
+		return nil
+	}
+	return program
+}
+
 func (s *scrapingService) extractBankName(cardName string) string {
 	// Enhanced bank extraction with more patterns
 	banks := map[string][]string{
@@ -692,5 +825,5 @@ func (s *scrapingService) parseIncomeRequirement(incomeText string) float64 {
 		}
 	}
 
-	return 30000 // Default minimum income
+	return 0 // Not listed; new cards get the default minimum income
 }
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"io"
+
+	"gotocard-backend/internal/config"
 	"gotocard-backend/internal/models"
 	"gotocard-backend/internal/repository"
 )
@@ -10,8 +13,14 @@ type UserService interface {
 	GetUserByID(id uint) (*models.User, error)
 	GetUserByEmail(email string) (*models.User, error)
 	UpdateUser(user *models.User) error
+	UpdateUserProfile(id uint, req *models.UpdateProfileRequest) (*models.User, error)
+	UpdateUserPreferences(id uint, req *models.UpdatePreferencesRequest) (*models.User, error)
 	DeleteUser(id uint) error
 	ListUsers() ([]models.User, error)
+	AddUserCard(userID uint, req *models.UserCardRequest) (*models.UserCard, error)
+	GetUserCards(userID uint) ([]models.UserCard, error)
+	UpdateUserCard(userID, userCardID uint, req *models.UserCardRequest) (*models.UserCard, error)
+	DeleteUserCard(userID, userCardID uint) error
 }
 
 type CategoryService interface {
@@ -32,6 +41,27 @@ type CreditCardService interface {
 	GetActiveCards() ([]models.CreditCard, error)
 }
 
+type LoyaltyProgramService interface {
+	CreateLoyaltyProgram(program *models.LoyaltyProgram) error
+	GetLoyaltyProgramByID(id uint) (*models.LoyaltyProgram, error)
+	UpdateLoyaltyProgram(program *models.LoyaltyProgram) error
+	DeleteLoyaltyProgram(id uint) error
+	ListLoyaltyPrograms() ([]models.LoyaltyProgram, error)
+	CreateTransferPartner(partner *models.TransferPartner) error
+	GetTransferPartnerByID(id uint) (*models.TransferPartner, error)
+	UpdateTransferPartner(partner *models.TransferPartner) error
+	DeleteTransferPartner(id uint) error
+	ListTransferPartners() ([]models.TransferPartner, error)
+}
+
+type MerchantService interface {
+	CreateMerchant(merchant *models.Merchant) error
+	GetMerchantByID(id uint) (*models.Merchant, error)
+	UpdateMerchant(merchant *models.Merchant) error
+	DeleteMerchant(id uint) error
+	ListMerchants() ([]models.Merchant, error)
+}
+
 type SpendingService interface {
 	AddSpending(userID uint, req *models.SpendingRequest) error
 	GetUserSpending(userID uint) ([]models.UserSpending, error)
@@ -41,11 +71,53 @@ type SpendingService interface {
 	DeleteSpending(id uint) error
 }
 
+// TransactionService keeps the user's transaction ledger, from which the
+// monthly spending the recommendation engine reads is derived.
+type TransactionService interface {
+	CreateTransaction(userID uint, req *models.TransactionRequest) (*models.Transaction, error)
+	GetTransaction(userID, transactionID uint) (*models.Transaction, error)
+	ListTransactions(userID uint, opts models.TransactionListOptions) (*models.TransactionListResponse, error)
+	UpdateTransaction(userID, transactionID uint, req *models.TransactionRequest) (*models.Transaction, error)
+	DeleteTransaction(userID, transactionID uint) error
+}
+
+// StatementService imports bank statement exports into the transaction
+// ledger, previewing the parsed and categorised lines first.
+type StatementService interface {
+	ListStatementBanks() []models.StatementBank
+	PreviewStatement(userID uint, bank string, cardID *uint, statement io.Reader) (*models.StatementPreview, error)
+	ImportStatement(userID uint, req *models.StatementImportRequest) (*models.StatementImportResult, error)
+}
+
 type RecommendationService interface {
-	GenerateRecommendations(userID uint) ([]models.RecommendationResponse, error)
+	GenerateRecommendations(userID uint, opts models.RecommendationOptions) ([]models.RecommendationResponse, error)
+	PreviewRecommendations(userID uint, opts models.RecommendationOptions) ([]models.RecommendationResponse, error)
 	GetRecommendationsByUser(userID uint) ([]models.RecommendationResponse, error)
-	GetRecommendationsByCategory(userID, categoryID uint) ([]models.RecommendationResponse, error)
+	GetRecommendationsByCategory(userID, categoryID uint, page models.PageOptions) (*models.CategoryRecommendationsResponse, error)
 	RefreshRecommendations(userID uint) error
+	OptimizeWallet(userID uint, req models.WalletRequest) (*models.WalletResponse, error)
+	ChooseCard(userID uint, req models.PurchaseRequest) (*models.PurchaseResponse, error)
+	SimulateRecommendations(userID uint, req *models.SimulationRequest) (*models.SimulationResponse, error)
+	ListRecommendationRuns(userID uint, opts models.RunListOptions) ([]models.RecommendationRun, error)
+	GetRecommendationRun(userID, runID uint) (*models.RecommendationRunResponse, error)
+	DiffRecommendationRuns(userID uint, req models.RunDiffRequest) (*models.RunDiff, error)
+}
+
+// BatchService regenerates stored recommendations for many users in the
+// background, for example after a catalog scrape.
+type BatchService interface {
+	StartRegeneration(req models.BatchJobRequest) (*models.BatchJob, error)
+	GetJob(id uint) (*models.BatchJob, error)
+	ListJobs() []models.BatchJob
+	CancelJob(id uint) (*models.BatchJob, error)
+}
+
+// NotificationService tells users when catalog changes produce a card that
+// beats their stored recommendations and keeps their in-app inbox.
+type NotificationService interface {
+	NotifyCatalogChange(cardIDs []uint) error
+	ListNotifications(userID uint, opts models.NotificationListOptions) ([]models.Notification, error)
+	MarkNotificationRead(userID, notificationID uint) (*models.Notification, error)
 }
 
 type ScrapingService interface {
@@ -58,18 +130,32 @@ type Services struct {
 	User           UserService
 	Category       CategoryService
 	CreditCard     CreditCardService
+	LoyaltyProgram LoyaltyProgramService
+	Merchant       MerchantService
 	Spending       SpendingService
+	Transaction    TransactionService
+	Statement      StatementService
 	Recommendation RecommendationService
+	Batch          BatchService
+	Notification   NotificationService
 	Scraping       ScrapingService
 }
 
-func NewServices(repos *repository.Repositories) *Services {
+func NewServices(repos *repository.Repositories, cfg *config.Config) *Services {
+	recommendation := NewRecommendationService(repos, cfg.Recommendation)
+	notification := NewNotificationService(repos, recommendation, cfg.Notification)
 	return &Services{
 		User:           NewUserService(repos),
 		Category:       NewCategoryService(repos),
 		CreditCard:     NewCreditCardService(repos),
+		LoyaltyProgram: NewLoyaltyProgramService(repos),
+		Merchant:       NewMerchantService(repos),
 		Spending:       NewSpendingService(repos),
-		Recommendation: NewRecommendationService(repos),
-		Scraping:       NewScrapingService(repos),
+		Transaction:    NewTransactionService(repos),
+		Statement:      NewStatementService(repos),
+		Recommendation: recommendation,
+		Batch:          NewBatchService(repos, recommendation),
+		Notification:   notification,
+		Scraping:       NewScrapingService(repos, notification),
 	}
 }
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"gotocard-backend/internal/models"
+)
+
+// ErrNegativeSpending is returned when a replacement simulation gives a
+// category a negative monthly amount.
+var ErrNegativeSpending = errors.New("monthly amount cannot be negative")
+
+// SimulateRecommendations runs the recommendation engine over a what-if
+// spending profile and returns the results next to the user's stored
+// recommendations. Nothing is written to the database.
+func (s *recommendationService) SimulateRecommendations(userID uint, req *models.SimulationRequest) (*models.SimulationResponse, error) {
+	user, err := s.repos.User.GetByID(userID)
+	if err != nil {
+		return nil, lookupError("user", err)
+	}
+
+	// A delta keeps the recorded merchant split and applies the change to
+	// every recorded month too; a replacement profile has neither
+	profile := spendingProfile{categories: make(map[uint]float64)}
+	if req.Mode == models.SimulationDelta {
+		spendings, err := s.repos.UserSpending.GetByUserID(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user spending: %w", err)
+		}
+		profile = buildSpendingProfile(spendings, req.WindowMonths, spendingCutoff(evaluationDate(req.RecommendationOptions)))
+
+		for _, spending := range req.Spending {
+			profile.categories[spending.CategoryID] += spending.MonthlyAmount
+			if profile.categories[spending.CategoryID] <= 0 {
+				delete(profile.categories, spending.CategoryID)
+			}
+			for _, month := range profile.history {
+				month[spending.CategoryID] = math.Max(0, month[spending.CategoryID]+spending.MonthlyAmount)
+			}
+		}
+	} else {
+		for _, spending := range req.Spending {
+			if spending.MonthlyAmount < 0 {
+				return nil, fmt.Errorf("category %d: %w", spending.CategoryID, ErrNegativeSpending)
+			}
+			profile.categories[spending.CategoryID] += spending.MonthlyAmount
+		}
+	}
+
+	strategy, err := resolveStrategy(user, req.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	cards, err := s.repos.CreditCard.GetActiveCardsAsOf(evaluationDate(req.RecommendationOptions))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active cards: %w", err)
+	}
+
+	simulated, err := s.rankRecommendations(user, cards, profile, strategy, req.RecommendationOptions)
+	if err != nil {
+		return nil, err
+	}
+	if len(simulated) > topRecommendations {
+		simulated = simulated[:topRecommendations]
+	}
+
+	current, err := s.GetRecommendationsByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored recommendations: %w", err)
+	}
+
+	// Report the profile that was simulated in a stable order
+	simulatedSpending := make([]models.SimulatedSpending, 0, len(profile.categories))
+	for categoryID, amount := range profile.categories {
+		simulatedSpending = append(simulatedSpending, models.SimulatedSpending{
+			CategoryID:    categoryID,
+			MonthlyAmount: roundCents(amount),
+		})
+	}
+	sort.Slice(simulatedSpending, func(i, j int) bool {
+		return simulatedSpending[i].CategoryID < simulatedSpending[j].CategoryID
+	})
+
+	return &models.SimulationResponse{
+		Spending:  simulatedSpending,
+		Simulated: simulated,
+		Current:   current,
+	}, nil
+}
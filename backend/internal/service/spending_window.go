@@ -0,0 +1,249 @@
+package service
+
+import (
+	"time"
+
+	"gotocard-backend/internal/models"
+)
+
+const defaultSpendingWindowMonths = 3
+
+// localCurrency is the currency spending amounts are recorded in; spend in
+// any other currency is overseas.
+const localCurrency = "SGD"
+
+// monthIndex maps a calendar month onto a linear scale so windows can be
+// computed with plain integer arithmetic.
+func monthIndex(month, year int) int {
+	return year*12 + (month - 1)
+}
+
+// spendingCutoff is the date spending is averaged up to when cards are
+// evaluated as of asOf. A past date only sees the spending recorded by then;
+// nothing is recorded beyond now, so a future date uses the latest spending.
+func spendingCutoff(asOf time.Time) time.Time {
+	if now := time.Now(); asOf.After(now) {
+		return now
+	}
+	return asOf
+}
+
+// preferLedgerSpending drops manually entered spending in categories and
+// months that also have spending derived from the transaction ledger. The
+// ledger records the category's month in full, so counting the manual totals
+// as well would count the same spend twice; manual spending in categories the
+// ledger has no transactions for is kept.
+func preferLedgerSpending(spendings []models.UserSpending) []models.UserSpending {
+	type categoryMonth struct {
+		month      int
+		categoryID uint
+	}
+	ledgered := make(map[categoryMonth]bool)
+	for _, spending := range spendings {
+		if spending.Derived {
+			ledgered[categoryMonth{monthIndex(spending.Month, spending.Year), spending.CategoryID}] = true
+		}
+	}
+	if len(ledgered) == 0 {
+		return spendings
+	}
+
+	preferred := make([]models.UserSpending, 0, len(spendings))
+	for _, spending := range spendings {
+		if spending.Derived || !ledgered[categoryMonth{monthIndex(spending.Month, spending.Year), spending.CategoryID}] {
+			preferred = append(preferred, spending)
+		}
+	}
+	return preferred
+}
+
+// monthElapsed returns how much of now's month has elapsed, counting today.
+func monthElapsed(now time.Time) float64 {
+	daysInMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day()
+	return float64(now.Day()) / float64(daysInMonth)
+}
+
+// spendingWindow locates the trailing window of windowMonths months used to
+// average spending and returns its first and last month indexes along with
+// the number of months the window's records span. A zero month count means
+// there is nothing to average.
+//
+// The window ends at the most recent complete month the user has recorded
+// spending for; the month still in progress is left out, as its records
+// only cover part of it. Months without any records are treated as missing
+// data and left out of the count. A user whose only records are in the
+// month in progress gets that month alone, weighted by the fraction of it
+// that has elapsed so its partial total is scaled to a whole month.
+func spendingWindow(spendings []models.UserSpending, windowMonths int, now time.Time) (start, end int, months float64) {
+	if windowMonths <= 0 {
+		windowMonths = defaultSpendingWindowMonths
+	}
+
+	current := monthIndex(int(now.Month()), now.Year())
+	end = -1
+	inProgress := false
+	for _, spending := range spendings {
+		index := monthIndex(spending.Month, spending.Year)
+		if index < current && index > end {
+			end = index
+		}
+		if index == current {
+			inProgress = true
+		}
+	}
+	if end < 0 {
+		if inProgress {
+			return current, current, monthElapsed(now)
+		}
+		return 0, end, 0
+	}
+
+	start = end - windowMonths + 1
+	observed := make(map[int]bool)
+	for _, spending := range spendings {
+		index := monthIndex(spending.Month, spending.Year)
+		if index >= start && index <= end {
+			observed[index] = true
+		}
+	}
+	return start, end, float64(len(observed))
+}
+
+// averageMonthlySpending converts raw spending rows into a per-month figure
+// for each category over the trailing window described by spendingWindow. A
+// category that is absent from a month that does have records counts as
+// zero spend for that month.
+func averageMonthlySpending(spendings []models.UserSpending, windowMonths int, now time.Time) map[uint]float64 {
+	averages := make(map[uint]float64)
+	start, end, months := spendingWindow(spendings, windowMonths, now)
+	if months == 0 {
+		return averages
+	}
+
+	for _, spending := range spendings {
+		index := monthIndex(spending.Month, spending.Year)
+		if index >= start && index <= end {
+			averages[spending.CategoryID] += spending.Amount / months
+		}
+	}
+
+	return averages
+}
+
+// merchantSpending is the monthly spend at known merchants, keyed by
+// category and then merchant. It is part of the category's spend, not in
+// addition to it.
+type merchantSpending map[uint]map[uint]float64
+
+// averageMonthlyMerchantSpending averages the spending recorded against a
+// merchant over the same window as averageMonthlySpending.
+func averageMonthlyMerchantSpending(spendings []models.UserSpending, windowMonths int, now time.Time) merchantSpending {
+	averages := make(merchantSpending)
+	start, end, months := spendingWindow(spendings, windowMonths, now)
+	if months == 0 {
+		return averages
+	}
+
+	for _, spending := range spendings {
+		index := monthIndex(spending.Month, spending.Year)
+		if spending.MerchantID == nil || index < start || index > end {
+			continue
+		}
+		if averages[spending.CategoryID] == nil {
+			averages[spending.CategoryID] = make(map[uint]float64)
+		}
+		averages[spending.CategoryID][*spending.MerchantID] += spending.Amount / months
+	}
+
+	return averages
+}
+
+// averageMonthlyOverseasSpending averages the spending recorded as overseas
+// by category over the same window as averageMonthlySpending.
+func averageMonthlyOverseasSpending(spendings []models.UserSpending, windowMonths int, now time.Time) map[uint]float64 {
+	averages := make(map[uint]float64)
+	start, end, months := spendingWindow(spendings, windowMonths, now)
+	if months == 0 {
+		return averages
+	}
+
+	for _, spending := range spendings {
+		index := monthIndex(spending.Month, spending.Year)
+		if spending.Overseas && index >= start && index <= end {
+			averages[spending.CategoryID] += spending.Amount / months
+		}
+	}
+
+	return averages
+}
+
+// spendingSplit is the part of each category's monthly spend with earn
+// rules of its own: spend at known merchants and spend overseas.
+type spendingSplit struct {
+	merchants merchantSpending
+	overseas  map[uint]float64
+}
+
+// monthlySpendingHistory returns each recorded month's spend by category in
+// the window described by spendingWindow, oldest first. A month in progress
+// is scaled to a whole month as its average is.
+func monthlySpendingHistory(spendings []models.UserSpending, windowMonths int, now time.Time) []map[uint]float64 {
+	start, end, months := spendingWindow(spendings, windowMonths, now)
+	if months == 0 {
+		return nil
+	}
+
+	byMonth := make(map[int]map[uint]float64)
+	for _, spending := range spendings {
+		index := monthIndex(spending.Month, spending.Year)
+		if index < start || index > end {
+			continue
+		}
+		if byMonth[index] == nil {
+			byMonth[index] = make(map[uint]float64)
+		}
+		byMonth[index][spending.CategoryID] += spending.Amount
+	}
+
+	var history []map[uint]float64
+	for index := start; index <= end; index++ {
+		month, ok := byMonth[index]
+		if !ok {
+			continue
+		}
+		if months < 1 {
+			for categoryID := range month {
+				month[categoryID] /= months
+			}
+		}
+		history = append(history, month)
+	}
+	return history
+}
+
+// spendingProfile is the spending the engine ranks cards against: the
+// average monthly spend per category, the parts of it at known merchants and
+// overseas, and the individual months behind the average.
+type spendingProfile struct {
+	categories map[uint]float64
+	merchants  merchantSpending
+	overseas   map[uint]float64
+	history    []map[uint]float64
+}
+
+func (p spendingProfile) split() spendingSplit {
+	return spendingSplit{merchants: p.merchants, overseas: p.overseas}
+}
+
+// buildSpendingProfile summarizes the user's spending over the trailing
+// window ending no later than now, preferring the ledger's totals for
+// months that have transactions.
+func buildSpendingProfile(spendings []models.UserSpending, windowMonths int, now time.Time) spendingProfile {
+	spendings = preferLedgerSpending(spendings)
+	return spendingProfile{
+		categories: averageMonthlySpending(spendings, windowMonths, now),
+		merchants:  averageMonthlyMerchantSpending(spendings, windowMonths, now),
+		overseas:   averageMonthlyOverseasSpending(spendings, windowMonths, now),
+		history:    monthlySpendingHistory(spendings, windowMonths, now),
+	}
+}
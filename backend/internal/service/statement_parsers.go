@@ -0,0 +1,325 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gotocard-backend/internal/models"
+)
+
+// Supported statement banks
+const (
+	BankDBS  = "dbs"
+	BankOCBC = "ocbc"
+	BankUOB  = "uob"
+	BankCiti = "citi"
+	BankHSBC = "hsbc"
+)
+
+// StatementParser reads one bank's CSV statement export. Each returned line
+// carries its row number, date, description and SGD amount, positive for
+// charges and negative for payments and refunds, plus the foreign currency
+// and amount where the export has them.
+type StatementParser interface {
+	Bank() string
+	Name() string
+	Parse(r io.Reader) ([]models.StatementLine, error)
+}
+
+var statementParsers = make(map[string]StatementParser)
+
+// RegisterStatementParser makes a bank's statements importable, replacing
+// any parser already registered for the bank.
+func RegisterStatementParser(parser StatementParser) {
+	statementParsers[parser.Bank()] = parser
+}
+
+// LookupStatementParser returns the parser registered for the bank code.
+func LookupStatementParser(bank string) (StatementParser, error) {
+	parser, ok := statementParsers[strings.ToLower(bank)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported statement bank %q", bank)
+	}
+	return parser, nil
+}
+
+// StatementParsers lists the registered parsers by bank code.
+func StatementParsers() []StatementParser {
+	list := make([]StatementParser, 0, len(statementParsers))
+	for _, parser := range statementParsers {
+		list = append(list, parser)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Bank() < list[j].Bank()
+	})
+	return list
+}
+
+// statementColumns names the columns of a statement export. A statement has
+// either separate debit and credit columns or a single amount column.
+type statementColumns struct {
+	date          string
+	description   string
+	debit         string
+	credit        string
+	amount        string
+	currency      string
+	foreignAmount string
+}
+
+// csvStatementParser parses the column-per-field CSV exports the banks
+// offer. Exports usually start with account details before the header row,
+// so rows are skipped until one holds the named columns. Exports without a
+// header row give the column names in fixedHeader instead.
+type csvStatementParser struct {
+	bank        string
+	name        string
+	columns     statementColumns
+	fixedHeader []string
+	dateLayouts []string
+	// chargesNegative is set when a single amount column shows charges as
+	// negative numbers
+	chargesNegative bool
+}
+
+func (p *csvStatementParser) Bank() string { return p.bank }
+
+func (p *csvStatementParser) Name() string { return p.name }
+
+func (p *csvStatementParser) Parse(r io.Reader) ([]models.StatementLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var index map[string]int
+	if p.fixedHeader != nil {
+		index = p.columnIndex(p.fixedHeader)
+	}
+
+	var lines []models.StatementLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s statement: %w", p.name, err)
+		}
+
+		if index == nil {
+			index = p.columnIndex(record)
+			continue
+		}
+
+		row, _ := reader.FieldPos(0)
+		line, ok, err := p.parseRow(record, index)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", row, err)
+		}
+		if ok {
+			line.Line = row
+			lines = append(lines, line)
+		}
+	}
+
+	if index == nil {
+		return nil, fmt.Errorf("no %s statement header found; expected a %q column", p.name, p.columns.date)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no transactions found in %s statement", p.name)
+	}
+	return lines, nil
+}
+
+// columnIndex maps the configured columns to their positions in a header
+// row. It returns nil if the row is not the header.
+func (p *csvStatementParser) columnIndex(header []string) map[string]int {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		positions[normalizeColumn(name)] = i
+	}
+
+	index := make(map[string]int)
+	for _, column := range []string{p.columns.date, p.columns.description, p.columns.debit,
+		p.columns.credit, p.columns.amount, p.columns.currency, p.columns.foreignAmount} {
+		if column == "" {
+			continue
+		}
+		position, ok := positions[normalizeColumn(column)]
+		if !ok {
+			return nil
+		}
+		index[column] = position
+	}
+	return index
+}
+
+// parseRow reads one statement row. Rows without a valid date, such as
+// totals and blank lines, are not transactions and are reported as not ok.
+func (p *csvStatementParser) parseRow(record []string, index map[string]int) (models.StatementLine, bool, error) {
+	cell := func(column string) string {
+		position, ok := index[column]
+		if column == "" || !ok || position >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[position])
+	}
+
+	date, ok := parseStatementDate(cell(p.columns.date), p.dateLayouts)
+	if !ok {
+		return models.StatementLine{}, false, nil
+	}
+	line := models.StatementLine{
+		Date:        date,
+		Description: strings.Join(strings.Fields(cell(p.columns.description)), " "),
+		Currency:    localCurrency,
+	}
+
+	if p.columns.amount != "" {
+		amount, err := parseStatementAmount(cell(p.columns.amount))
+		if err != nil {
+			return line, false, err
+		}
+		if p.chargesNegative {
+			amount = -amount
+		}
+		line.Amount = amount
+	} else {
+		debit, err := parseStatementAmount(cell(p.columns.debit))
+		if err != nil {
+			return line, false, err
+		}
+		credit, err := parseStatementAmount(cell(p.columns.credit))
+		if err != nil {
+			return line, false, err
+		}
+		line.Amount = debit - credit
+	}
+	line.Amount = roundCents(line.Amount)
+
+	if currency := strings.ToUpper(cell(p.columns.currency)); currency != "" && currency != localCurrency {
+		foreign, err := parseStatementAmount(cell(p.columns.foreignAmount))
+		if err != nil {
+			return line, false, err
+		}
+		if foreign < 0 {
+			foreign = -foreign
+		}
+		line.Currency = currency
+		line.OriginalAmount = foreign
+		line.Overseas = true
+	}
+	return line, true, nil
+}
+
+func normalizeColumn(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff")
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+func parseStatementDate(value string, layouts []string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseStatementAmount reads an amount as the banks print it: with currency
+// symbols and thousands separators, credits as a CR suffix, a leading minus
+// or parentheses. An empty cell is zero.
+func parseStatementAmount(value string) (float64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	if text == "" {
+		return 0, nil
+	}
+
+	negative := false
+	if strings.HasSuffix(text, "CR") {
+		negative = true
+		text = strings.TrimSpace(strings.TrimSuffix(text, "CR"))
+	} else if strings.HasSuffix(text, "DR") {
+		text = strings.TrimSpace(strings.TrimSuffix(text, "DR"))
+	}
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+		negative = true
+		text = strings.Trim(text, "()")
+	}
+	text = strings.NewReplacer("SGD", "", "S$", "", "$", "", ",", "", " ", "").Replace(text)
+
+	amount, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func init() {
+	RegisterStatementParser(&csvStatementParser{
+		bank: BankDBS,
+		name: "DBS",
+		columns: statementColumns{
+			date:        "Transaction Date",
+			description: "Transaction Description",
+			debit:       "Debit Amount",
+			credit:      "Credit Amount",
+		},
+		dateLayouts: []string{"02 Jan 2006", "2 Jan 2006"},
+	})
+	RegisterStatementParser(&csvStatementParser{
+		bank: BankOCBC,
+		name: "OCBC",
+		columns: statementColumns{
+			date:        "Transaction date",
+			description: "Description",
+			debit:       "Withdrawals (SGD)",
+			credit:      "Deposits (SGD)",
+		},
+		dateLayouts: []string{"02/01/2006", "2/1/2006"},
+	})
+	RegisterStatementParser(&csvStatementParser{
+		bank: BankUOB,
+		name: "UOB",
+		columns: statementColumns{
+			date:          "Transaction Date",
+			description:   "Description",
+			amount:        "Transaction Amount(Local)",
+			currency:      "Foreign Currency Type",
+			foreignAmount: "Transaction Amount(Foreign)",
+		},
+		dateLayouts: []string{"02 Jan 2006", "2 Jan 2006"},
+	})
+	RegisterStatementParser(&csvStatementParser{
+		bank: BankCiti,
+		name: "Citibank",
+		columns: statementColumns{
+			date:        "Date",
+			description: "Description",
+			amount:      "Amount",
+		},
+		fixedHeader:     []string{"Date", "Description", "Amount"},
+		dateLayouts:     []string{"02/01/2006", "2/1/2006"},
+		chargesNegative: true,
+	})
+	RegisterStatementParser(&csvStatementParser{
+		bank: BankHSBC,
+		name: "HSBC",
+		columns: statementColumns{
+			date:        "Transaction Date",
+			description: "Transaction Details",
+			amount:      "Billing Amount",
+		},
+		dateLayouts: []string{"02-01-2006", "2-1-2006"},
+	})
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/repository"
+)
+
+// statementKeywords suggest a category for statement lines that match no
+// known merchant, keyed by category name. Keywords are matched against the
+// normalised description, so they are upper case without punctuation.
+var statementKeywords = map[string][]string{
+	"Dining":        {"RESTAURANT", "CAFE", "COFFEE", "STARBUCKS", "MCDONALD", "KFC", "TOAST BOX", "YA KUN", "KOPITIAM", "BAKERY", "FOOD"},
+	"Groceries":     {"NTUC", "GIANT", "SUPERMARKET", "DON DON DONKI", "PRIME SUPERMARKET"},
+	"Petrol":        {"CALTEX", "SPC", "SINOPEC"},
+	"Transport":     {"TRANSITLINK", "BUS MRT", "SIMPLYGO", "COMFORTDELGRO", "CDG TAXI", "TADA", "RYDE", "PARKING"},
+	"Travel":        {"AIRLINES", "AIRWAYS", "SCOOT", "HOTEL", "AGODA", "BOOKING COM", "EXPEDIA", "TRIP COM", "KLOOK"},
+	"Entertainment": {"SPOTIFY", "GOLDEN VILLAGE", "SHAW THEATRES", "CATHAY CINEPLEX", "STEAM", "DISNEY", "PLAYSTATION"},
+	"Healthcare":    {"CLINIC", "HOSPITAL", "PHARMACY", "GUARDIAN", "WATSONS", "DENTAL", "MEDICAL"},
+	"Bills":         {"SINGTEL", "STARHUB", "SP SERVICES", "SP DIGITAL", "INSURANCE", "PRUDENTIAL", "GREAT EASTERN", "TOWN COUNCIL"},
+	"Shopping":      {"UNIQLO", "ZARA", "TAKASHIMAYA", "ISETAN", "TANGS", "IKEA", "COURTS", "BEST DENKI", "HARVEY NORMAN"},
+	"Online":        {"PAYPAL", "APPLE COM", "GOOGLE", "TAOBAO", "QOO10"},
+}
+
+// statementService turns bank statement exports into ledger transactions.
+type statementService struct {
+	repos *repository.Repositories
+}
+
+func NewStatementService(repos *repository.Repositories) StatementService {
+	return &statementService{repos: repos}
+}
+
+func (s *statementService) ListStatementBanks() []models.StatementBank {
+	parsers := StatementParsers()
+	banks := make([]models.StatementBank, 0, len(parsers))
+	for _, parser := range parsers {
+		banks = append(banks, models.StatementBank{Code: parser.Bank(), Name: parser.Name()})
+	}
+	return banks
+}
+
+// PreviewStatement parses a statement, matches each charge to a merchant or
+// category and flags lines already imported. Nothing is saved.
+func (s *statementService) PreviewStatement(userID uint, bank string, cardID *uint, statement io.Reader) (*models.StatementPreview, error) {
+	if _, err := s.repos.User.GetByID(userID); err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if err := checkCardHeld(s.repos, userID, cardID); err != nil {
+		return nil, err
+	}
+
+	parser, err := LookupStatementParser(bank)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parser.Parse(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	categorize, err := s.categorizer()
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.StatementPreview{
+		Bank:    parser.Bank(),
+		CardID:  cardID,
+		Lines:   []models.StatementLine{},
+		Skipped: []models.SkippedStatementLine{},
+	}
+	for _, line := range parsed {
+		if line.Amount <= 0 {
+			preview.Skipped = append(preview.Skipped, models.SkippedStatementLine{
+				Line:        line.Line,
+				Description: line.Description,
+				Amount:      line.Amount,
+				Reason:      "payment, refund or other credit",
+			})
+			continue
+		}
+		line.MerchantID, line.CategoryID = categorize(line.Description)
+		preview.Lines = append(preview.Lines, line)
+	}
+
+	if err := s.markDuplicates(userID, parser.Bank(), cardID, preview.Lines); err != nil {
+		return nil, err
+	}
+
+	var total float64
+	for _, line := range preview.Lines {
+		total += line.Amount
+		if line.Duplicate {
+			preview.Duplicates++
+		}
+		if line.CategoryID == 0 {
+			preview.Uncategorized++
+		}
+	}
+	preview.Total = roundCents(total)
+	return preview, nil
+}
+
+// ImportStatement records the lines as transactions, leaving out lines
+// already in the ledger. The transactions are saved and the derived monthly
+// spending rebuilt for every month they fall in within one database
+// transaction, so a failed rebuild leaves nothing imported.
+func (s *statementService) ImportStatement(userID uint, req *models.StatementImportRequest) (*models.StatementImportResult, error) {
+	if _, err := s.repos.User.GetByID(userID); err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	parser, err := LookupStatementParser(req.Bank)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCardHeld(s.repos, userID, req.CardID); err != nil {
+		return nil, err
+	}
+
+	lines := req.Lines
+	if err := s.markDuplicates(userID, parser.Bank(), req.CardID, lines); err != nil {
+		return nil, err
+	}
+
+	result := &models.StatementImportResult{Transactions: []models.Transaction{}}
+	var transactions []models.Transaction
+	for _, line := range lines {
+		if line.Duplicate {
+			result.Duplicates++
+			continue
+		}
+
+		categoryID, err := spendCategory(s.repos, line.CategoryID, line.MerchantID)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.Line, err)
+		}
+		currency, overseas := spendCurrency(line.Currency, line.Overseas)
+		channel := line.Channel
+		if channel == "" {
+			channel = models.ChannelInStore
+		}
+
+		transactions = append(transactions, models.Transaction{
+			UserID:         userID,
+			Date:           line.Date.UTC(),
+			Description:    line.Description,
+			MerchantID:     line.MerchantID,
+			CategoryID:     categoryID,
+			CardID:         req.CardID,
+			Amount:         line.Amount,
+			Currency:       currency,
+			OriginalAmount: line.OriginalAmount,
+			Overseas:       overseas,
+			Channel:        channel,
+			Fingerprint:    line.Fingerprint,
+		})
+	}
+	if len(transactions) == 0 {
+		return result, nil
+	}
+
+	err = s.repos.InTransaction(func(tx *repository.Repositories) error {
+		if err := tx.Transaction.CreateBatch(transactions); err != nil {
+			return fmt.Errorf("failed to import statement: %w", err)
+		}
+
+		ledger := &transactionService{repos: tx}
+		rebuilt := make(map[string]bool)
+		for _, transaction := range transactions {
+			month := transaction.Date.Format("2006-01")
+			if rebuilt[month] {
+				continue
+			}
+			rebuilt[month] = true
+			if err := ledger.rebuildMonth(userID, transaction.Date); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transaction := range transactions {
+		saved, err := s.repos.Transaction.GetByID(transaction.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load imported transaction: %w", err)
+		}
+		result.Transactions = append(result.Transactions, *saved)
+	}
+	result.Imported = len(result.Transactions)
+	return result, nil
+}
+
+// markDuplicates fingerprints each line and flags those the user's ledger
+// already holds. Identical lines on the same day are told apart by their
+// order, so repeated purchases within a statement are all kept while a
+// statement imported twice, or two overlapping statements, are not. The
+// bank and card are part of the fingerprint, so the same charge on two
+// cards' statements is not taken for a duplicate.
+func (s *statementService) markDuplicates(userID uint, bank string, cardID *uint, lines []models.StatementLine) error {
+	var card uint
+	if cardID != nil {
+		card = *cardID
+	}
+
+	seen := make(map[string]int)
+	fingerprints := make([]string, 0, len(lines))
+	for i := range lines {
+		key := fmt.Sprintf("%s|%d|%s|%d|%s", bank, card, lines[i].Date.UTC().Format("2006-01-02"),
+			int64(math.Round(lines[i].Amount*100)), normalizeDescription(lines[i].Description))
+		occurrence := seen[key]
+		seen[key]++
+
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, occurrence)))
+		lines[i].Fingerprint = hex.EncodeToString(sum[:])
+		fingerprints = append(fingerprints, lines[i].Fingerprint)
+	}
+
+	existing, err := s.repos.Transaction.ExistingFingerprints(userID, fingerprints)
+	if err != nil {
+		return fmt.Errorf("failed to check for imported transactions: %w", err)
+	}
+	for i := range lines {
+		lines[i].Duplicate = existing[lines[i].Fingerprint]
+	}
+	return nil
+}
+
+// categorizer matches statement descriptions to the merchant catalog, the
+// longest merchant name winning, and falls back to category keywords. It
+// returns no category when neither matches.
+func (s *statementService) categorizer() (func(description string) (*uint, uint), error) {
+	merchants, err := s.repos.Merchant.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merchants: %w", err)
+	}
+	categories, err := s.repos.Category.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	return func(description string) (*uint, uint) {
+		text := " " + normalizeDescription(description) + " "
+
+		var match *models.Merchant
+		for i := range merchants {
+			name := normalizeDescription(merchants[i].Name)
+			if name == "" || !strings.Contains(text, " "+name+" ") {
+				continue
+			}
+			if match == nil || len(name) > len(normalizeDescription(match.Name)) {
+				match = &merchants[i]
+			}
+		}
+		if match != nil {
+			merchantID := match.ID
+			return &merchantID, match.CategoryID
+		}
+
+		// The longest keyword wins; ties go to the first category listed
+		var categoryID uint
+		var longest int
+		for _, category := range categories {
+			for _, keyword := range statementKeywords[category.Name] {
+				if len(keyword) > longest && strings.Contains(text, " "+keyword+" ") {
+					categoryID, longest = category.ID, len(keyword)
+				}
+			}
+		}
+		return nil, categoryID
+	}, nil
+}
+
+// normalizeDescription upper-cases a description and reduces punctuation to
+// single spaces, so "GRAB*RIDES" and "Grab Rides" compare equal.
+func normalizeDescription(description string) string {
+	fields := strings.FieldsFunc(strings.ToUpper(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"gotocard-backend/internal/models"
+)
+
+// Built-in recommendation strategy names
+const (
+	StrategyBalanced    = "balanced"
+	StrategyMaxCashback = "max_cashback"
+	StrategyMaxMiles    = "max_miles"
+	StrategyNoFee       = "no_fee"
+)
+
+// defaultStrategy is used when neither the request nor the user's
+// preferences name a strategy.
+const defaultStrategy = StrategyBalanced
+
+// RecommendationStrategy decides which cards are worth recommending for a
+// category and how they are scored. The engine fills in the breakdown first;
+// a strategy only chooses the terms summed into the raw score.
+type RecommendationStrategy interface {
+	Name() string
+	Description() string
+	// Accepts reports whether the card should be recommended at all.
+	Accepts(benefit *models.CardBenefit, breakdown *models.ScoreBreakdown) bool
+	// Components returns the signed terms of the card's raw score.
+	Components(benefit *models.CardBenefit, breakdown *models.ScoreBreakdown) []models.ScoreComponent
+}
+
+var strategies = make(map[string]RecommendationStrategy)
+
+// ErrUnknownStrategy is returned when a request names a strategy that is not
+// registered.
+var ErrUnknownStrategy = errors.New("unknown recommendation strategy")
+
+// RegisterStrategy makes a strategy selectable by name, replacing any
+// strategy already registered under that name.
+func RegisterStrategy(strategy RecommendationStrategy) {
+	strategies[strategy.Name()] = strategy
+}
+
+// LookupStrategy returns the strategy registered under name, or the default
+// strategy for an empty name.
+func LookupStrategy(name string) (RecommendationStrategy, error) {
+	if name == "" {
+		name = defaultStrategy
+	}
+	strategy, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownStrategy, name)
+	}
+	return strategy, nil
+}
+
+// Strategies lists the registered strategies by name.
+func Strategies() []RecommendationStrategy {
+	list := make([]RecommendationStrategy, 0, len(strategies))
+	for _, strategy := range strategies {
+		list = append(list, strategy)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name() < list[j].Name()
+	})
+	return list
+}
+
+// resolveStrategy picks the requested strategy, falling back to the user's
+// saved preference and then the default.
+func resolveStrategy(user *models.User, requested string) (RecommendationStrategy, error) {
+	if requested == "" {
+		requested = user.Preferences.Strategy
+	}
+	return LookupStrategy(requested)
+}
+
+func init() {
+	RegisterStrategy(balancedStrategy{})
+	RegisterStrategy(maxCashbackStrategy{})
+	RegisterStrategy(maxMilesStrategy{})
+	RegisterStrategy(noFeeStrategy{})
+}
+
+// expectedFee is the fee the breakdown expects after waivers.
+func expectedFee(breakdown *models.ScoreBreakdown) float64 {
+	return breakdown.AnnualFee - breakdown.FeeWaiver
+}
+
+// balancedStrategy weighs net benefit, the headline reward rate and the fee.
+type balancedStrategy struct{}
+
+func (balancedStrategy) Name() string { return StrategyBalanced }
+
+func (balancedStrategy) Description() string {
+	return "Net benefit with a bonus for high reward rates and a penalty for annual fees"
+}
+
+func (balancedStrategy) Accepts(*models.CardBenefit, *models.ScoreBreakdown) bool { return true }
+
+func (balancedStrategy) Components(_ *models.CardBenefit, breakdown *models.ScoreBreakdown) []models.ScoreComponent {
+	return []models.ScoreComponent{
+		// Base score from net benefit
+		{Label: "net_benefit", Value: breakdown.NetBenefit},
+		// Bonus for higher reward rates, valued in dollars
+		{Label: "reward_rate_bonus", Value: breakdown.RewardRate * 10},
+		// Penalty for annual fee
+		{Label: "annual_fee_penalty", Value: -expectedFee(breakdown) * 0.5},
+	}
+}
+
+// maxCashbackStrategy ranks purely by dollars returned net of fees.
+type maxCashbackStrategy struct{}
+
+func (maxCashbackStrategy) Name() string { return StrategyMaxCashback }
+
+func (maxCashbackStrategy) Description() string {
+	return "Highest net benefit in dollars after fees"
+}
+
+func (maxCashbackStrategy) Accepts(*models.CardBenefit, *models.ScoreBreakdown) bool { return true }
+
+func (maxCashbackStrategy) Components(_ *models.CardBenefit, breakdown *models.ScoreBreakdown) []models.ScoreComponent {
+	return []models.ScoreComponent{
+		{Label: "net_benefit", Value: breakdown.NetBenefit},
+	}
+}
+
+// maxMilesStrategy only considers cards earning miles, directly or by
+// transferring points to an airline, and ranks them by the miles earned in a
+// year, one point per 100 miles.
+type maxMilesStrategy struct{}
+
+func (maxMilesStrategy) Name() string { return StrategyMaxMiles }
+
+func (maxMilesStrategy) Description() string {
+	return "Most miles earned per year, directly or through points transfers"
+}
+
+func (maxMilesStrategy) Accepts(benefit *models.CardBenefit, breakdown *models.ScoreBreakdown) bool {
+	return earnsMiles(benefit) || breakdown.TransferPartner != ""
+}
+
+func (maxMilesStrategy) Components(_ *models.CardBenefit, breakdown *models.ScoreBreakdown) []models.ScoreComponent {
+	return []models.ScoreComponent{
+		{Label: "miles_earned", Value: breakdown.AnnualMiles / 100},
+		{Label: "annual_fee_penalty", Value: -expectedFee(breakdown) * 0.1},
+	}
+}
+
+// noFeeStrategy only considers cards that cost nothing to hold once fee
+// waivers are taken into account.
+type noFeeStrategy struct{}
+
+func (noFeeStrategy) Name() string { return StrategyNoFee }
+
+func (noFeeStrategy) Description() string {
+	return "Cards with no expected annual fee, ranked by net benefit"
+}
+
+func (noFeeStrategy) Accepts(_ *models.CardBenefit, breakdown *models.ScoreBreakdown) bool {
+	return expectedFee(breakdown) <= 0
+}
+
+func (noFeeStrategy) Components(_ *models.CardBenefit, breakdown *models.ScoreBreakdown) []models.ScoreComponent {
+	return []models.ScoreComponent{
+		{Label: "net_benefit", Value: breakdown.NetBenefit},
+		{Label: "reward_rate_bonus", Value: breakdown.RewardRate * 10},
+	}
+}
+
+// earnsMiles reports whether the benefit's rewards are paid in miles.
+func earnsMiles(benefit *models.CardBenefit) bool {
+	if benefit.CashbackRate > 0 || benefit.PointsRate > 0 {
+		return false
+	}
+	if benefit.MilesRate > 0 {
+		return true
+	}
+	for _, tier := range benefit.Tiers {
+		if tier.MilesRate > 0 {
+			return true
+		}
+	}
+	return false
+}
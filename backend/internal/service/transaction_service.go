@@ -0,0 +1,234 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"gotocard-backend/internal/models"
+	"gotocard-backend/internal/repository"
+)
+
+// defaultTransactionPageSize is the page size for transaction listings
+const defaultTransactionPageSize = 20
+
+// transactionService keeps the user's transaction ledger. Every change
+// rebuilds the derived monthly spending for the months it touches in the
+// same database transaction, so the recommendation engine sees the ledger
+// through UserSpending.
+type transactionService struct {
+	repos *repository.Repositories
+}
+
+func NewTransactionService(repos *repository.Repositories) TransactionService {
+	return &transactionService{repos: repos}
+}
+
+func (s *transactionService) CreateTransaction(userID uint, req *models.TransactionRequest) (*models.Transaction, error) {
+	if _, err := s.repos.User.GetByID(userID); err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	transaction := &models.Transaction{UserID: userID}
+	if err := s.apply(transaction, req); err != nil {
+		return nil, err
+	}
+
+	err := s.repos.InTransaction(func(tx *repository.Repositories) error {
+		if err := tx.Transaction.Create(transaction); err != nil {
+			return fmt.Errorf("failed to add transaction: %w", err)
+		}
+		ledger := &transactionService{repos: tx}
+		return ledger.rebuildMonth(userID, transaction.Date)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.Transaction.GetByID(transaction.ID)
+}
+
+func (s *transactionService) GetTransaction(userID, transactionID uint) (*models.Transaction, error) {
+	return s.transaction(userID, transactionID)
+}
+
+// ListTransactions pages through the user's transactions, newest first.
+func (s *transactionService) ListTransactions(userID uint, opts models.TransactionListOptions) (*models.TransactionListResponse, error) {
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultTransactionPageSize
+	}
+
+	transactions, total, err := s.repos.Transaction.List(userID, opts, (opts.Page-1)*opts.PageSize, opts.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	return &models.TransactionListResponse{
+		Transactions: transactions,
+		Page:         opts.Page,
+		PageSize:     opts.PageSize,
+		Total:        total,
+	}, nil
+}
+
+// UpdateTransaction replaces a transaction. Moving it to another month
+// rebuilds both the old and the new month.
+func (s *transactionService) UpdateTransaction(userID, transactionID uint, req *models.TransactionRequest) (*models.Transaction, error) {
+	transaction, err := s.transaction(userID, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousDate := transaction.Date
+	if err := s.apply(transaction, req); err != nil {
+		return nil, err
+	}
+
+	err = s.repos.InTransaction(func(tx *repository.Repositories) error {
+		if err := tx.Transaction.Update(transaction); err != nil {
+			return fmt.Errorf("failed to update transaction: %w", err)
+		}
+		ledger := &transactionService{repos: tx}
+		if err := ledger.rebuildMonth(userID, transaction.Date); err != nil {
+			return err
+		}
+		if !sameMonth(previousDate, transaction.Date) {
+			return ledger.rebuildMonth(userID, previousDate)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.Transaction.GetByID(transaction.ID)
+}
+
+func (s *transactionService) DeleteTransaction(userID, transactionID uint) error {
+	transaction, err := s.transaction(userID, transactionID)
+	if err != nil {
+		return err
+	}
+
+	return s.repos.InTransaction(func(tx *repository.Repositories) error {
+		if err := tx.Transaction.Delete(transactionID); err != nil {
+			return fmt.Errorf("failed to delete transaction: %w", err)
+		}
+		ledger := &transactionService{repos: tx}
+		return ledger.rebuildMonth(userID, transaction.Date)
+	})
+}
+
+// apply validates a request against the catalog and the user's cards and
+// copies it onto the transaction.
+func (s *transactionService) apply(transaction *models.Transaction, req *models.TransactionRequest) error {
+	categoryID, err := spendCategory(s.repos, req.CategoryID, req.MerchantID)
+	if err != nil {
+		return err
+	}
+	if err := checkCardHeld(s.repos, transaction.UserID, req.CardID); err != nil {
+		return err
+	}
+	currency, overseas := spendCurrency(req.Currency, req.Overseas)
+
+	channel := req.Channel
+	if channel == "" {
+		channel = models.ChannelInStore
+	}
+
+	transaction.Date = req.Date.UTC()
+	transaction.Description = req.Description
+	transaction.MerchantID = req.MerchantID
+	transaction.CategoryID = categoryID
+	transaction.CardID = req.CardID
+	transaction.Amount = req.Amount
+	transaction.Currency = currency
+	transaction.OriginalAmount = req.OriginalAmount
+	transaction.Overseas = overseas
+	transaction.Channel = channel
+
+	// The preloaded relationships are stale once the IDs change
+	transaction.Category = models.Category{}
+	transaction.Merchant = nil
+	transaction.Card = nil
+	return nil
+}
+
+// spendingKey groups a month's transactions into derived spending records.
+type spendingKey struct {
+	categoryID uint
+	merchantID uint
+	cardID     uint
+	overseas   bool
+	currency   string
+}
+
+// rebuildMonth replaces the user's derived spending for the month containing
+// date with totals of that month's transactions, one record per category,
+// merchant, card and currency.
+func (s *transactionService) rebuildMonth(userID uint, date time.Time) error {
+	date = date.UTC()
+	month, year := int(date.Month()), date.Year()
+
+	transactions, err := s.repos.Transaction.GetByUserAndMonth(userID, month, year)
+	if err != nil {
+		return fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	totals := make(map[spendingKey]*models.UserSpending)
+	var spendings []*models.UserSpending
+	for _, transaction := range transactions {
+		key := spendingKey{
+			categoryID: transaction.CategoryID,
+			overseas:   transaction.Overseas,
+			currency:   transaction.Currency,
+		}
+		if transaction.MerchantID != nil {
+			key.merchantID = *transaction.MerchantID
+		}
+		if transaction.CardID != nil {
+			key.cardID = *transaction.CardID
+		}
+
+		spending, ok := totals[key]
+		if !ok {
+			spending = &models.UserSpending{
+				CategoryID: transaction.CategoryID,
+				MerchantID: transaction.MerchantID,
+				CardID:     transaction.CardID,
+				Overseas:   transaction.Overseas,
+				Currency:   transaction.Currency,
+			}
+			totals[key] = spending
+			spendings = append(spendings, spending)
+		}
+		spending.Amount += transaction.Amount
+		spending.OriginalAmount += transaction.OriginalAmount
+	}
+
+	derived := make([]models.UserSpending, 0, len(spendings))
+	for _, spending := range spendings {
+		spending.Amount = roundCents(spending.Amount)
+		spending.OriginalAmount = roundCents(spending.OriginalAmount)
+		derived = append(derived, *spending)
+	}
+
+	if err := s.repos.UserSpending.ReplaceDerived(userID, month, year, derived); err != nil {
+		return fmt.Errorf("failed to update monthly spending: %w", err)
+	}
+	return nil
+}
+
+// transaction loads a transaction and checks that it belongs to the user.
+func (s *transactionService) transaction(userID, transactionID uint) (*models.Transaction, error) {
+	transaction, err := s.repos.Transaction.GetByID(transactionID)
+	if err != nil || transaction.UserID != userID {
+		return nil, fmt.Errorf("transaction %d not found for user %d", transactionID, userID)
+	}
+	return transaction, nil
+}
+
+func sameMonth(a, b time.Time) bool {
+	a, b = a.UTC(), b.UTC()
+	return a.Year() == b.Year() && a.Month() == b.Month()
+}
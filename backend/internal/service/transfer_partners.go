@@ -0,0 +1,110 @@
+package service
+
+import (
+	"math"
+
+	"gotocard-backend/internal/models"
+)
+
+// bestTransferPartner returns the program's transfer partner giving the most
+// value per point before fees, or nil if it has none.
+func bestTransferPartner(program *models.LoyaltyProgram) *models.TransferPartner {
+	var best *models.TransferPartner
+	for i := range program.TransferPartners {
+		partner := &program.TransferPartners[i]
+		if partner.ToProgram == nil || partner.Ratio <= 0 {
+			continue
+		}
+		if best == nil || transferCents(partner) > transferCents(best) {
+			best = partner
+		}
+	}
+	return best
+}
+
+// transferCents is the value in cents of one point converted through the
+// partner, before conversion fees.
+func transferCents(partner *models.TransferPartner) float64 {
+	return partner.Ratio * partner.ToProgram.CentsPerUnit
+}
+
+// transferPartnerName names the partner's airline program for display.
+func transferPartnerName(partner *models.TransferPartner) string {
+	return partner.ToProgram.Name
+}
+
+// annualConversionFees is the expected yearly fee for converting the given
+// number of points a year. Points are converted once a year, or once every
+// few years when a year's points do not fill a block; leftover points carry
+// over to the next conversion.
+func annualConversionFees(partner *models.TransferPartner, annualPoints float64) float64 {
+	if annualPoints <= 0 || partner.ConversionFee <= 0 {
+		return 0
+	}
+	conversions := 1.0
+	if partner.BlockSize > 0 {
+		conversions = math.Min(1, annualPoints/partner.BlockSize)
+	}
+	return partner.ConversionFee * conversions
+}
+
+// earnsPoints reports whether the benefit's rewards are paid in points.
+func earnsPoints(benefit *models.CardBenefit) bool {
+	if benefit.CashbackRate > 0 {
+		return false
+	}
+	if benefit.PointsRate > 0 {
+		return true
+	}
+	for _, tier := range benefit.Tiers {
+		if tier.CashbackRate <= 0 && tier.PointsRate > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// transferredReward is the monthly reward a card earns through one transfer
+// partner in each category.
+type transferredReward struct {
+	partner          *models.TransferPartner
+	directPointCents float64
+	categories       map[uint]float64
+}
+
+// applyConversionCosts charges each transfer partner's conversion fees
+// against the categories whose points go through it, pro rata to their
+// reward, and records the miles each category earns through transfer. If
+// the fees leave the points worth less than their direct value, the user is
+// assumed to redeem them directly instead.
+func applyConversionCosts(evaluation *cardEvaluation, transfers map[uint]*transferredReward) {
+	for _, transfer := range transfers {
+		var annualValue float64
+		for _, reward := range transfer.categories {
+			annualValue += reward * 12
+		}
+		if annualValue <= 0 {
+			continue
+		}
+
+		grossCents := transferCents(transfer.partner)
+		annualPoints := annualValue * 100 / grossCents
+		feeShare := annualConversionFees(transfer.partner, annualPoints) / annualValue
+		directShare := 1 - transfer.directPointCents/grossCents
+
+		for categoryID, reward := range transfer.categories {
+			result := evaluation.categories[categoryID]
+			if feeShare >= directShare {
+				// Not worth converting; the points are redeemed directly
+				result.reward -= reward * directShare
+			} else {
+				result.reward -= reward * feeShare
+				result.conversionFee += reward * 12 * feeShare
+				result.transferMiles += reward * 100 / transfer.partner.ToProgram.CentsPerUnit
+				result.transferPartner = transfer.partner
+			}
+			result.merchantReward = math.Min(result.merchantReward, result.reward)
+			evaluation.categories[categoryID] = result
+		}
+	}
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"fmt"
+	"strings"
+
 	"gotocard-backend/internal/models"
 	"gotocard-backend/internal/repository"
 )
@@ -58,6 +60,44 @@ func (s *userService) UpdateUser(user *models.User) error {
 	return nil
 }
 
+func (s *userService) UpdateUserProfile(id uint, req *models.UpdateProfileRequest) (*models.User, error) {
+	user, err := s.repos.User.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	user.Profile = req.UserProfile
+
+	err = s.repos.User.Update(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user profile: %w", err)
+	}
+	return user, nil
+}
+
+func (s *userService) UpdateUserPreferences(id uint, req *models.UpdatePreferencesRequest) (*models.User, error) {
+	user, err := s.repos.User.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if req.Strategy != nil {
+		if _, err := LookupStrategy(*req.Strategy); err != nil {
+			return nil, err
+		}
+		user.Preferences.Strategy = *req.Strategy
+	}
+	if req.Notifications != nil {
+		user.Preferences.Notifications = *req.Notifications
+	}
+
+	err = s.repos.User.Update(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user preferences: %w", err)
+	}
+	return user, nil
+}
+
 func (s *userService) DeleteUser(id uint) error {
 	// Check if user exists
 	_, err := s.repos.User.GetByID(id)
@@ -86,6 +126,91 @@ func (s *userService) ListUsers() ([]models.User, error) {
 	return users, nil
 }
 
+// AddUserCard records a card the user already holds.
+func (s *userService) AddUserCard(userID uint, req *models.UserCardRequest) (*models.UserCard, error) {
+	if _, err := s.repos.User.GetByID(userID); err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if _, err := s.repos.CreditCard.GetByID(req.CardID); err != nil {
+		return nil, fmt.Errorf("credit card not found: %w", err)
+	}
+
+	if existing, err := s.repos.UserCard.GetByUserAndCard(userID, req.CardID); err == nil && existing != nil {
+		return nil, fmt.Errorf("user already holds card %d", req.CardID)
+	}
+
+	userCard := &models.UserCard{
+		UserID:           userID,
+		CardID:           req.CardID,
+		Since:            req.Since,
+		CreditLimit:      req.CreditLimit,
+		AnniversaryMonth: req.AnniversaryMonth,
+	}
+
+	err := s.repos.UserCard.Create(userCard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add user card: %w", err)
+	}
+	return s.repos.UserCard.GetByID(userCard.ID)
+}
+
+func (s *userService) GetUserCards(userID uint) ([]models.UserCard, error) {
+	userCards, err := s.repos.UserCard.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user cards: %w", err)
+	}
+	return userCards, nil
+}
+
+func (s *userService) UpdateUserCard(userID, userCardID uint, req *models.UserCardRequest) (*models.UserCard, error) {
+	userCard, err := s.userCard(userID, userCardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CardID != userCard.CardID {
+		if _, err := s.repos.CreditCard.GetByID(req.CardID); err != nil {
+			return nil, fmt.Errorf("credit card not found: %w", err)
+		}
+		if existing, err := s.repos.UserCard.GetByUserAndCard(userID, req.CardID); err == nil && existing != nil {
+			return nil, fmt.Errorf("user already holds card %d", req.CardID)
+		}
+	}
+
+	userCard.CardID = req.CardID
+	userCard.Since = req.Since
+	userCard.CreditLimit = req.CreditLimit
+	userCard.AnniversaryMonth = req.AnniversaryMonth
+
+	err = s.repos.UserCard.Update(userCard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user card: %w", err)
+	}
+	return s.repos.UserCard.GetByID(userCard.ID)
+}
+
+func (s *userService) DeleteUserCard(userID, userCardID uint) error {
+	if _, err := s.userCard(userID, userCardID); err != nil {
+		return err
+	}
+
+	err := s.repos.UserCard.Delete(userCardID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user card: %w", err)
+	}
+	return nil
+}
+
+// userCard loads a held card and checks that it belongs to the user.
+func (s *userService) userCard(userID, userCardID uint) (*models.UserCard, error) {
+	userCard, err := s.repos.UserCard.GetByID(userCardID)
+	if err != nil || userCard.UserID != userID {
+		return nil, fmt.Errorf("user card %d not found", userCardID)
+	}
+	return userCard, nil
+}
+
 type categoryService struct {
 	repos *repository.Repositories
 }
@@ -219,18 +344,26 @@ func (s *spendingService) AddSpending(userID uint, req *models.SpendingRequest)
 		return fmt.Errorf("user not found: %w", err)
 	}
 
-	// Verify category exists
-	_, err = s.repos.Category.GetByID(req.CategoryID)
+	categoryID, err := spendCategory(s.repos, req.CategoryID, req.MerchantID)
 	if err != nil {
-		return fmt.Errorf("category not found: %w", err)
+		return err
 	}
+	if err := checkCardHeld(s.repos, userID, req.CardID); err != nil {
+		return err
+	}
+	currency, overseas := spendCurrency(req.Currency, req.Overseas)
 
 	spending := &models.UserSpending{
-		UserID:     userID,
-		CategoryID: req.CategoryID,
-		Amount:     req.Amount,
-		Month:      req.Month,
-		Year:       req.Year,
+		UserID:         userID,
+		CategoryID:     categoryID,
+		MerchantID:     req.MerchantID,
+		CardID:         req.CardID,
+		Amount:         req.Amount,
+		Overseas:       overseas,
+		Currency:       currency,
+		OriginalAmount: req.OriginalAmount,
+		Month:          req.Month,
+		Year:           req.Year,
 	}
 
 	err = s.repos.UserSpending.Create(spending)
@@ -240,6 +373,46 @@ func (s *spendingService) AddSpending(userID uint, req *models.SpendingRequest)
 	return nil
 }
 
+// spendCategory resolves the category spend falls in: the given one, or the
+// merchant's own category when none is given.
+func spendCategory(repos *repository.Repositories, categoryID uint, merchantID *uint) (uint, error) {
+	if merchantID != nil {
+		merchant, err := repos.Merchant.GetByID(*merchantID)
+		if err != nil {
+			return 0, fmt.Errorf("merchant not found: %w", err)
+		}
+		if categoryID == 0 {
+			categoryID = merchant.CategoryID
+		}
+	}
+
+	if _, err := repos.Category.GetByID(categoryID); err != nil {
+		return 0, fmt.Errorf("category not found: %w", err)
+	}
+	return categoryID, nil
+}
+
+// checkCardHeld ensures spend is only charged to a card the user holds.
+func checkCardHeld(repos *repository.Repositories, userID uint, cardID *uint) error {
+	if cardID == nil {
+		return nil
+	}
+	if _, err := repos.UserCard.GetByUserAndCard(userID, *cardID); err != nil {
+		return fmt.Errorf("card %d is not held by user %d: %w", *cardID, userID, err)
+	}
+	return nil
+}
+
+// spendCurrency normalises the currency spend was charged in. Spend in a
+// foreign currency is always overseas.
+func spendCurrency(currency string, overseas bool) (string, bool) {
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = localCurrency
+	}
+	return currency, overseas || currency != localCurrency
+}
+
 func (s *spendingService) GetUserSpending(userID uint) ([]models.UserSpending, error) {
 	spendings, err := s.repos.UserSpending.GetByUserID(userID)
 	if err != nil {
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gotocard-backend/internal/models"
+)
+
+const (
+	defaultWalletSize = 3
+	// maxWalletCombinations bounds the exhaustive search; larger searches
+	// fall back to greedy selection with swap refinement.
+	maxWalletCombinations = 200000
+)
+
+// walletCandidate is a card together with its annual reward per category.
+type walletCandidate struct {
+	card    models.CreditCard
+	rewards map[uint]float64
+}
+
+// OptimizeWallet picks up to MaxCards cards and a category-to-card assignment
+// that maximizes the total annual reward minus each chosen card's annual fee.
+func (s *recommendationService) OptimizeWallet(userID uint, req models.WalletRequest) (*models.WalletResponse, error) {
+	user, err := s.repos.User.GetByID(userID)
+	if err != nil {
+		return nil, lookupError("user", err)
+	}
+
+	spendings, err := s.repos.UserSpending.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user spending: %w", err)
+	}
+
+	asOf := evaluationDate(req.RecommendationOptions)
+	cards, err := s.repos.CreditCard.GetActiveCardsAsOf(asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active cards: %w", err)
+	}
+	cards = eligibleCards(user.Profile, cards)
+
+	categories, err := s.repos.Category.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	maxCards := req.MaxCards
+	if maxCards <= 0 {
+		maxCards = defaultWalletSize
+	}
+
+	profile := buildSpendingProfile(spendings, req.WindowMonths, spendingCutoff(asOf))
+	optimizer := &walletOptimizer{
+		engine:     s,
+		spending:   profile.categories,
+		split:      profile.split(),
+		candidates: s.walletCandidates(profile.categories, profile.split(), cards),
+		horizon:    req.Horizon,
+		now:        asOf,
+	}
+	wallet := optimizer.selectWallet(maxCards)
+
+	return optimizer.buildResponse(wallet, categories), nil
+}
+
+// walletCandidates computes the annual reward each card earns per category
+// with the whole profile charged to it, and drops cards that earn nothing.
+func (s *recommendationService) walletCandidates(categorySpending map[uint]float64, split spendingSplit, cards []models.CreditCard) []walletCandidate {
+	var candidates []walletCandidate
+	for _, card := range cards {
+		rewards := make(map[uint]float64)
+		for categoryID, result := range s.evaluateCard(card, categorySpending, split).categories {
+			if result.reward > 0 {
+				rewards[categoryID] = result.reward * 12
+			}
+		}
+
+		if len(rewards) > 0 {
+			candidates = append(candidates, walletCandidate{card: card, rewards: rewards})
+		}
+	}
+	return candidates
+}
+
+// walletOptimizer searches combinations of candidate cards for one user's
+// spending profile.
+type walletOptimizer struct {
+	engine     *recommendationService
+	spending   map[uint]float64
+	split      spendingSplit
+	candidates []walletCandidate
+	horizon    string
+	now        time.Time
+}
+
+// assign charges each category to the wallet card with the highest
+// standalone reward and returns the spending each card receives.
+func (o *walletOptimizer) assign(wallet []walletCandidate) []map[uint]float64 {
+	assigned := make([]map[uint]float64, len(wallet))
+	for i := range assigned {
+		assigned[i] = make(map[uint]float64)
+	}
+
+	for categoryID, spent := range o.spending {
+		bestIndex := -1
+		bestReward := 0.0
+		for i, candidate := range wallet {
+			if reward := candidate.rewards[categoryID]; reward > bestReward {
+				bestIndex, bestReward = i, reward
+			}
+		}
+		if bestIndex >= 0 {
+			assigned[bestIndex][categoryID] = spent
+		}
+	}
+	return assigned
+}
+
+// walletSettlement is what each wallet card earns on its assigned spending.
+type walletSettlement struct {
+	rewards []map[uint]float64 // annual reward per category
+	bonuses []float64          // welcome bonus counted for the horizon
+	fees    []float64          // expected annual fee for the horizon
+}
+
+// settle re-evaluates each wallet card against only the spending assigned to
+// it, so card-level minimum spend, shared caps, welcome offer qualifying
+// spend and spend-based fee waivers reflect what the card actually receives.
+func (o *walletOptimizer) settle(wallet []walletCandidate) walletSettlement {
+	assigned := o.assign(wallet)
+	settlement := walletSettlement{
+		rewards: make([]map[uint]float64, len(wallet)),
+		bonuses: make([]float64, len(wallet)),
+		fees:    make([]float64, len(wallet)),
+	}
+
+	for i, candidate := range wallet {
+		evaluation := o.engine.evaluateCard(candidate.card, assigned[i], o.split)
+		settlement.rewards[i] = make(map[uint]float64)
+		for categoryID, result := range evaluation.categories {
+			settlement.rewards[i][categoryID] = result.reward * 12
+		}
+		settlement.fees[i] = expectedFees(candidate.card, evaluation.totalSpend*12).forHorizon(o.horizon)
+
+		if o.horizon == models.HorizonFirstYear || o.horizon == models.HorizonAmortized {
+			welcome := evaluateWelcomeOffer(candidate.card, evaluation.totalSpend, o.now)
+			if welcome.qualified {
+				settlement.bonuses[i] = welcome.value
+				if o.horizon == models.HorizonAmortized {
+					settlement.bonuses[i] /= welcomeAmortizationYears
+				}
+			}
+		}
+	}
+	return settlement
+}
+
+// value returns the net annual value of a wallet for the horizon: the
+// rewards on the assigned spending plus any welcome bonus, minus every
+// card's expected fee once.
+func (o *walletOptimizer) value(wallet []walletCandidate) float64 {
+	settlement := o.settle(wallet)
+	var total float64
+	for i, rewards := range settlement.rewards {
+		total += settlement.bonuses[i] - settlement.fees[i]
+		for _, reward := range rewards {
+			total += reward
+		}
+	}
+	return total
+}
+
+// selectWallet returns the best wallet of at most maxCards candidates. Small
+// searches are solved exactly; larger ones use a greedy heuristic.
+func (o *walletOptimizer) selectWallet(maxCards int) []walletCandidate {
+	if maxCards > len(o.candidates) {
+		maxCards = len(o.candidates)
+	}
+
+	var combinations float64
+	for k := 1; k <= maxCards; k++ {
+		combinations += binomial(len(o.candidates), k)
+	}
+
+	if combinations <= maxWalletCombinations {
+		return o.exhaustive(maxCards)
+	}
+	return o.greedy(maxCards)
+}
+
+func (o *walletOptimizer) exhaustive(maxCards int) []walletCandidate {
+	var best []walletCandidate
+	bestValue := 0.0
+	current := make([]walletCandidate, 0, maxCards)
+
+	var search func(start int)
+	search = func(start int) {
+		if len(current) > 0 {
+			if value := o.value(current); value > bestValue {
+				bestValue = value
+				best = append([]walletCandidate(nil), current...)
+			}
+		}
+		if len(current) == maxCards {
+			return
+		}
+		for i := start; i < len(o.candidates); i++ {
+			current = append(current, o.candidates[i])
+			search(i + 1)
+			current = current[:len(current)-1]
+		}
+	}
+	search(0)
+
+	return best
+}
+
+func (o *walletOptimizer) greedy(maxCards int) []walletCandidate {
+	var chosen []int
+	inWallet := make(map[int]bool)
+	value := 0.0
+
+	pick := func(indices []int) []walletCandidate {
+		wallet := make([]walletCandidate, len(indices))
+		for i, index := range indices {
+			wallet[i] = o.candidates[index]
+		}
+		return wallet
+	}
+
+	// Add the card with the largest marginal gain until no card helps
+	for len(chosen) < maxCards {
+		bestIndex := -1
+		bestValue := value
+		for i := range o.candidates {
+			if inWallet[i] {
+				continue
+			}
+			if v := o.value(pick(append(chosen, i))); v > bestValue {
+				bestIndex, bestValue = i, v
+			}
+		}
+		if bestIndex < 0 {
+			break
+		}
+		chosen = append(chosen, bestIndex)
+		inWallet[bestIndex] = true
+		value = bestValue
+	}
+
+	// Swap cards out while any single swap improves the wallet
+	for improved := true; improved; {
+		improved = false
+		for slot := range chosen {
+			for i := range o.candidates {
+				if inWallet[i] {
+					continue
+				}
+				trial := append([]int(nil), chosen...)
+				trial[slot] = i
+				if v := o.value(pick(trial)); v > value+1e-9 {
+					delete(inWallet, chosen[slot])
+					inWallet[i] = true
+					chosen, value = trial, v
+					improved = true
+				}
+			}
+		}
+	}
+
+	return pick(chosen)
+}
+
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return result
+}
+
+func (o *walletOptimizer) buildResponse(wallet []walletCandidate, categories []models.Category) *models.WalletResponse {
+	response := &models.WalletResponse{
+		Cards:       []models.CreditCard{},
+		Assignments: []models.WalletAssignment{},
+	}
+
+	settlement := o.settle(wallet)
+	for i, candidate := range wallet {
+		response.Cards = append(response.Cards, candidate.card)
+		response.TotalAnnualFee += settlement.fees[i]
+		response.WelcomeBonus += settlement.bonuses[i]
+	}
+
+	for _, category := range categories {
+		for i := range wallet {
+			reward, ok := settlement.rewards[i][category.ID]
+			if !ok {
+				continue
+			}
+
+			response.Assignments = append(response.Assignments, models.WalletAssignment{
+				Category:     category,
+				CardID:       wallet[i].card.ID,
+				CardName:     wallet[i].card.Name,
+				MonthlySpend: roundCents(o.spending[category.ID]),
+				AnnualReward: roundCents(reward),
+			})
+			response.TotalAnnualReward += reward
+		}
+	}
+
+	sort.Slice(response.Assignments, func(i, j int) bool {
+		return response.Assignments[i].AnnualReward > response.Assignments[j].AnnualReward
+	})
+
+	response.NetBenefit = roundCents(response.TotalAnnualReward + response.WelcomeBonus - response.TotalAnnualFee)
+	response.WelcomeBonus = roundCents(response.WelcomeBonus)
+	response.TotalAnnualReward = roundCents(response.TotalAnnualReward)
+	response.TotalAnnualFee = roundCents(response.TotalAnnualFee)
+
+	return response
+}
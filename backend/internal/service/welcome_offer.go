@@ -0,0 +1,76 @@
+package service
+
+import (
+	"time"
+
+	"gotocard-backend/internal/models"
+)
+
+const (
+	// defaultQualifyingMonths applies to offers that do not state a window
+	defaultQualifyingMonths = 3
+	// welcomeAmortizationYears spreads a welcome bonus for the amortized horizon
+	welcomeAmortizationYears = 3
+)
+
+// welcomeValue is a card's welcome offer as seen by the engine.
+type welcomeValue struct {
+	value         float64
+	qualified     bool
+	newToBankOnly bool
+}
+
+// activeWelcomeOffer returns the card's most valuable offer valid at now.
+// ValidUntil is inclusive of its whole day.
+func activeWelcomeOffer(card models.CreditCard, now time.Time) *models.WelcomeOffer {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var best *models.WelcomeOffer
+	bestValue := 0.0
+	valuation := valuationFor(card, nil)
+	for i := range card.WelcomeOffers {
+		offer := &card.WelcomeOffers[i]
+		if offer.ValidFrom != nil && now.Before(*offer.ValidFrom) {
+			continue
+		}
+		if offer.ValidUntil != nil && offer.ValidUntil.Before(day) {
+			continue
+		}
+		if value := welcomeOfferValue(offer, valuation); value > bestValue {
+			best, bestValue = offer, value
+		}
+	}
+	return best
+}
+
+// welcomeOfferValue converts the offer's reward into dollars.
+func welcomeOfferValue(offer *models.WelcomeOffer, valuation rewardValuation) float64 {
+	switch offer.RewardType {
+	case models.WelcomeRewardPoints:
+		return offer.RewardAmount * valuation.pointCents / 100
+	case models.WelcomeRewardMiles:
+		return offer.RewardAmount * valuation.mileCents / 100
+	default:
+		return offer.RewardAmount
+	}
+}
+
+// evaluateWelcomeOffer values the card's current welcome offer and checks
+// whether the monthly spend charged to the card meets its qualifying spend
+// within the qualifying window.
+func evaluateWelcomeOffer(card models.CreditCard, monthlySpend float64, now time.Time) welcomeValue {
+	offer := activeWelcomeOffer(card, now)
+	if offer == nil {
+		return welcomeValue{}
+	}
+
+	months := offer.QualifyingMonths
+	if months <= 0 {
+		months = defaultQualifyingMonths
+	}
+
+	return welcomeValue{
+		value:         welcomeOfferValue(offer, valuationFor(card, nil)),
+		qualified:     monthlySpend*float64(months) >= offer.QualifyingSpend,
+		newToBankOnly: offer.NewToBankOnly,
+	}
+}
@@ -35,6 +35,8 @@ func (v *Validator) formatValidationError(err validator.FieldError) string {
 	switch tag {
 	case "required":
 		return fmt.Sprintf("%s is required", field)
+	case "required_without":
+		return fmt.Sprintf("%s is required without %s", field, strings.ToLower(err.Param()))
 	case "email":
 		return fmt.Sprintf("%s must be a valid email address", field)
 	case "min":
@@ -9,7 +9,21 @@ import {
   CategoriesResponse,
   CardsResponse,
   SpendingsResponse,
-  RecommendationsResponse
+  RecommendationsResponse,
+  CategoryRecommendationsResponse,
+  BatchJob,
+  BatchJobRequest,
+  Notification,
+  PurchaseRequest,
+  PurchaseResponse,
+  Transaction,
+  TransactionRequest,
+  TransactionListOptions,
+  TransactionListResponse,
+  StatementBank,
+  StatementPreview,
+  StatementImportRequest,
+  StatementImportResult
 } from '../types';
 
 const API_BASE_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080';
@@ -53,6 +67,14 @@ export const userAPI = {
     
   getAll: (): Promise<UsersResponse> =>
     api.get('/users').then(res => res.data),
+
+  getNotifications: (id: number, unreadOnly = false): Promise<{ notifications: Notification[] }> =>
+    api.get(`/users/${id}/notifications`, {
+      params: unreadOnly ? { unread: true } : undefined,
+    }).then(res => res.data),
+
+  markNotificationRead: (id: number, notificationId: number): Promise<{ message: string; notification: Notification }> =>
+    api.post(`/users/${id}/notifications/${notificationId}/read`).then(res => res.data),
 };
 
 // Category API
@@ -82,19 +104,80 @@ export const spendingAPI = {
     api.get(`/spending/users/${userId}`).then(res => res.data),
 };
 
+// Transaction API
+export const transactionAPI = {
+  list: (userId: number, options: TransactionListOptions = {}): Promise<TransactionListResponse> =>
+    api.get(`/users/${userId}/transactions`, { params: options }).then(res => res.data),
+
+  get: (userId: number, transactionId: number): Promise<{ transaction: Transaction }> =>
+    api.get(`/users/${userId}/transactions/${transactionId}`).then(res => res.data),
+
+  create: (userId: number, transaction: TransactionRequest): Promise<{ message: string; transaction: Transaction }> =>
+    api.post(`/users/${userId}/transactions`, transaction).then(res => res.data),
+
+  update: (userId: number, transactionId: number, transaction: TransactionRequest): Promise<{ message: string; transaction: Transaction }> =>
+    api.put(`/users/${userId}/transactions/${transactionId}`, transaction).then(res => res.data),
+
+  delete: (userId: number, transactionId: number): Promise<{ message: string }> =>
+    api.delete(`/users/${userId}/transactions/${transactionId}`).then(res => res.data),
+};
+
+// Statement import API
+export const statementAPI = {
+  getBanks: (): Promise<{ banks: StatementBank[] }> =>
+    api.get('/statements/banks').then(res => res.data),
+
+  preview: (userId: number, bank: string, file: File, cardId?: number): Promise<{ preview: StatementPreview }> => {
+    const form = new FormData();
+    form.append('bank', bank);
+    form.append('file', file);
+    if (cardId) {
+      form.append('card_id', String(cardId));
+    }
+    return api.post(`/users/${userId}/statements/preview`, form, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    }).then(res => res.data);
+  },
+
+  import: (userId: number, request: StatementImportRequest): Promise<{ message: string; import: StatementImportResult }> =>
+    api.post(`/users/${userId}/statements/import`, request).then(res => res.data),
+};
+
 // Recommendation API
 export const recommendationAPI = {
-  generate: (userId: number): Promise<RecommendationsResponse> =>
-    api.post(`/recommendations/users/${userId}/generate`).then(res => res.data),
+  generate: (userId: number, windowMonths?: number): Promise<RecommendationsResponse> =>
+    api.post(`/recommendations/users/${userId}/generate`, null, {
+      params: windowMonths ? { window_months: windowMonths } : undefined,
+    }).then(res => res.data),
     
   getByUser: (userId: number): Promise<RecommendationsResponse> =>
     api.get(`/recommendations/users/${userId}`).then(res => res.data),
+
+  getByCategory: (userId: number, categoryId: number, page = 1, pageSize = 10): Promise<CategoryRecommendationsResponse> =>
+    api.get(`/recommendations/users/${userId}/categories/${categoryId}`, {
+      params: { page, page_size: pageSize },
+    }).then(res => res.data),
+
+  bestCard: (userId: number, purchase: PurchaseRequest): Promise<{ purchase: PurchaseResponse }> =>
+    api.get(`/recommendations/users/${userId}/best-card`, { params: purchase }).then(res => res.data),
 };
 
 // Admin API
 export const adminAPI = {
   scrapeCards: (): Promise<{ message: string }> =>
     api.post('/admin/scrape').then(res => res.data),
+
+  startBatch: (request: BatchJobRequest = {}): Promise<{ message: string; job: BatchJob }> =>
+    api.post('/admin/recommendations/batch', request).then(res => res.data),
+
+  listBatches: (): Promise<{ jobs: BatchJob[] }> =>
+    api.get('/admin/recommendations/batch').then(res => res.data),
+
+  getBatch: (id: number): Promise<{ job: BatchJob }> =>
+    api.get(`/admin/recommendations/batch/${id}`).then(res => res.data),
+
+  cancelBatch: (id: number): Promise<{ message: string; job: BatchJob }> =>
+    api.post(`/admin/recommendations/batch/${id}/cancel`).then(res => res.data),
 };
 
 export default api; 
\ No newline at end of file
@@ -2,10 +2,18 @@ export interface User {
   id: number;
   name: string;
   email: string;
+  profile: UserProfile;
+  preferences: UserPreferences;
   created_at: string;
   updated_at: string;
 }
 
+export interface UserProfile {
+  annual_income: number;
+  age: number;
+  residency_status: '' | 'citizen' | 'pr' | 'foreigner';
+}
+
 export interface Category {
   id: number;
   name: string;
@@ -15,6 +23,14 @@ export interface Category {
   updated_at: string;
 }
 
+export interface Merchant {
+  id: number;
+  name: string;
+  category_id: number;
+  description: string;
+  category?: Category;
+}
+
 export interface CreditCard {
   id: number;
   name: string;
@@ -26,11 +42,83 @@ export interface CreditCard {
   min_income: number;
   welcome_bonus?: string;
   is_active: boolean;
+  effective_from?: string;
+  effective_to?: string;
+  rules: CardRules;
+  fee_waiver: FeeWaiverRules;
+  base_rate: BaseEarnRate;
+  overseas: OverseasTerms;
+  excluded_categories?: Category[];
   card_benefits?: CardBenefit[];
+  cap_groups?: CardCapGroup[];
+  welcome_offers?: WelcomeOffer[];
   created_at: string;
   updated_at: string;
 }
 
+export interface WelcomeOffer {
+  id: number;
+  card_id: number;
+  reward_type: 'cashback' | 'gift' | 'points' | 'miles';
+  reward_amount: number;
+  qualifying_spend: number;
+  qualifying_months: number;
+  new_to_bank_only: boolean;
+  valid_from?: string;
+  valid_until?: string;
+  description: string;
+}
+
+export interface CardRules {
+  min_total_spend: number;
+}
+
+export interface BaseEarnRate {
+  cashback_rate: number;
+  points_rate: number;
+  miles_rate: number;
+}
+
+export interface OverseasTerms {
+  fx_fee_rate: number;
+  cashback_rate: number;
+  points_rate: number;
+  miles_rate: number;
+}
+
+export interface FeeWaiverRules {
+  first_years_waived: number;
+  spend_threshold: number;
+  auto_waiver_probability: number;
+}
+
+export interface LoyaltyProgram {
+  id: number;
+  name: string;
+  type: 'points' | 'miles';
+  issuer: string;
+  cents_per_unit: number;
+  description: string;
+  transfer_partners?: TransferPartner[];
+}
+
+export interface TransferPartner {
+  id: number;
+  from_program_id: number;
+  to_program_id: number;
+  ratio: number;
+  block_size: number;
+  conversion_fee: number;
+  to_program?: LoyaltyProgram;
+}
+
+export interface CardCapGroup {
+  id: number;
+  card_id: number;
+  name: string;
+  reward_cap: number;
+}
+
 export interface CardBenefit {
   id: number;
   card_id: number;
@@ -40,22 +128,108 @@ export interface CardBenefit {
   miles_rate: number;
   cap: number;
   min_spend: number;
+  tier_mode: 'threshold' | 'marginal';
+  tiers?: BenefitTier[];
+  merchants?: Merchant[];
+  cap_group_id?: number;
+  effective_from?: string;
+  effective_to?: string;
   description: string;
   category: Category;
   created_at: string;
   updated_at: string;
 }
 
+export interface BenefitTier {
+  id: number;
+  benefit_id: number;
+  min_spend: number;
+  cashback_rate: number;
+  points_rate: number;
+  miles_rate: number;
+  cap: number;
+}
+
+export interface UserCard {
+  id: number;
+  user_id: number;
+  card_id: number;
+  since?: string;
+  credit_limit: number;
+  anniversary_month: number;
+  card: CreditCard;
+  created_at: string;
+  updated_at: string;
+}
+
 export interface UserSpending {
   id: number;
   user_id: number;
   category_id: number;
+  merchant_id?: number;
+  card_id?: number;
   amount: number;
+  overseas: boolean;
+  currency: string;
+  original_amount?: number;
   month: number;
   year: number;
+  derived: boolean;
+  category: Category;
+  merchant?: Merchant;
+  created_at: string;
+  updated_at: string;
+}
+
+export type TransactionChannel = 'in_store' | 'online' | 'contactless';
+
+export interface Transaction {
+  id: number;
+  user_id: number;
+  date: string;
+  description: string;
+  merchant_id?: number;
+  category_id: number;
+  card_id?: number;
+  amount: number;
+  currency: string;
+  original_amount?: number;
+  overseas: boolean;
+  channel: TransactionChannel;
   category: Category;
+  merchant?: Merchant;
+  card?: CreditCard;
+  created_at: string;
+  updated_at: string;
+}
+
+export type RecommendationStrategy = 'balanced' | 'max_cashback' | 'max_miles' | 'no_fee';
+
+export interface NotificationPreferences {
+  muted: boolean;
+  email: boolean;
+  webhook_url: string;
+  min_improvement: number;
+}
+
+export interface UserPreferences {
+  strategy: RecommendationStrategy | '';
+  notifications: NotificationPreferences;
+}
+
+export interface Notification {
+  id: number;
+  user_id: number;
+  card_id: number;
+  category_id: number;
+  title: string;
+  message: string;
+  improvement: number;
+  read_at?: string | null;
   created_at: string;
   updated_at: string;
+  card: CreditCard;
+  category: Category;
 }
 
 export interface Recommendation {
@@ -65,6 +239,117 @@ export interface Recommendation {
   score: number;
   estimated_reward: number;
   reason: string;
+  breakdown: ScoreBreakdown;
+  strategy: RecommendationStrategy;
+  eligible: boolean;
+  ineligible_reason?: string;
+}
+
+export interface ScoreComponent {
+  label: string;
+  value: number;
+}
+
+export interface ScoreBreakdown {
+  monthly_spend: number;
+  gross_reward: number;
+  capped_amount: number;
+  monthly_reward: number;
+  annual_reward: number;
+  min_spend: number;
+  min_total_spend: number;
+  min_spend_met: boolean;
+  annual_fee: number;
+  fee_waiver: number;
+  net_benefit: number;
+  welcome_bonus: number;
+  welcome_bonus_qualified: boolean;
+  welcome_new_to_bank_only: boolean;
+  first_year_value: number;
+  ongoing_value: number;
+  amortized_value: number;
+  horizon: 'ongoing' | 'first_year' | 'amortized';
+  reward_rate: number;
+  base_rate: boolean;
+  baseline_reward: number;
+  incremental_reward: number;
+  merchant_reward: number;
+  annual_miles: number;
+  miles_per_dollar: number;
+  transfer_partner?: string;
+  conversion_fee: number;
+  overseas_spend: number;
+  overseas_rate: boolean;
+  fx_fees: number;
+  expected_reward: number;
+  reward_low: number;
+  reward_high: number;
+  min_spend_probability: number;
+  history_months: number;
+  components: ScoreComponent[];
+  raw_score: number;
+  final_score: number;
+}
+
+export interface RecommendationRun {
+  id: number;
+  user_id: number;
+  engine_version: string;
+  spending_hash: string;
+  catalog_version: string;
+  options: {
+    window_months: number;
+    include_ineligible: boolean;
+    horizon: string;
+    per_category: number;
+    strategy: RecommendationStrategy;
+    as_of?: string;
+  };
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ScoreChange {
+  card_id: number;
+  card_name: string;
+  category_id: number;
+  category_name: string;
+  from_score: number;
+  to_score: number;
+  delta: number;
+  from_rank: number;
+  to_rank: number;
+}
+
+export interface RunDiff {
+  from: RecommendationRun;
+  to: RecommendationRun;
+  spending_changed: boolean;
+  catalog_changed: boolean;
+  engine_changed: boolean;
+  entered: Recommendation[];
+  left: Recommendation[];
+  score_changes: ScoreChange[];
+}
+
+export type BatchJobStatus = 'running' | 'completed' | 'cancelled';
+
+export interface BatchJobError {
+  user_id: number;
+  error: string;
+}
+
+export interface BatchJob {
+  id: number;
+  status: BatchJobStatus;
+  total: number;
+  processed: number;
+  succeeded: number;
+  failed: number;
+  skipped: number;
+  errors: BatchJobError[];
+  started_at: string;
+  finished_at?: string;
 }
 
 // Request DTOs
@@ -73,13 +358,146 @@ export interface CreateUserRequest {
   email: string;
 }
 
+export interface UpdatePreferencesRequest {
+  strategy?: RecommendationStrategy | '';
+  notifications?: NotificationPreferences;
+}
+
+export interface UserCardRequest {
+  card_id: number;
+  since?: string;
+  credit_limit?: number;
+  anniversary_month?: number;
+}
+
+export interface BatchJobRequest {
+  user_ids?: number[];
+  stale_only?: boolean;
+  concurrency?: number;
+  options?: Partial<RecommendationRun['options']>;
+}
+
 export interface SpendingRequest {
-  category_id: number;
+  category_id?: number;
+  merchant_id?: number;
+  card_id?: number;
   amount: number;
+  overseas?: boolean;
+  currency?: string;
+  original_amount?: number;
   month: number;
   year: number;
 }
 
+export interface TransactionRequest {
+  date: string;
+  description: string;
+  category_id?: number;
+  merchant_id?: number;
+  card_id?: number;
+  amount: number;
+  currency?: string;
+  original_amount?: number;
+  overseas?: boolean;
+  channel?: TransactionChannel;
+}
+
+export interface TransactionListOptions {
+  from?: string;
+  to?: string;
+  category_id?: number;
+  merchant_id?: number;
+  card_id?: number;
+  channel?: TransactionChannel;
+  page?: number;
+  page_size?: number;
+}
+
+export interface TransactionListResponse {
+  transactions: Transaction[];
+  page: number;
+  page_size: number;
+  total: number;
+}
+
+export interface StatementBank {
+  code: string;
+  name: string;
+}
+
+export interface StatementLine {
+  line: number;
+  date: string;
+  description: string;
+  amount: number;
+  currency: string;
+  original_amount: number;
+  overseas: boolean;
+  merchant_id?: number;
+  category_id: number;
+  channel?: TransactionChannel;
+  fingerprint: string;
+  duplicate: boolean;
+}
+
+export interface SkippedStatementLine {
+  line: number;
+  description: string;
+  amount: number;
+  reason: string;
+}
+
+export interface StatementPreview {
+  bank: string;
+  card_id?: number;
+  lines: StatementLine[];
+  skipped: SkippedStatementLine[];
+  total: number;
+  duplicates: number;
+  uncategorized: number;
+}
+
+export interface StatementImportRequest {
+  bank: string;
+  card_id?: number;
+  lines: StatementLine[];
+}
+
+export interface StatementImportResult {
+  imported: number;
+  duplicates: number;
+  transactions: Transaction[];
+}
+
+export interface PurchaseRequest {
+  merchant_id?: number;
+  category_id?: number;
+  amount: number;
+  overseas?: boolean;
+  date?: string;
+}
+
+export interface CardChoice {
+  card: CreditCard;
+  marginal_reward: number;
+  effective_rate: number;
+  benefit_spend: number;
+  cap: number;
+  cap_remaining?: number;
+  min_spend: number;
+  min_spend_met: boolean;
+  fx_fee: number;
+  reason: string;
+}
+
+export interface PurchaseResponse {
+  category: Category;
+  merchant?: Merchant;
+  amount: number;
+  date: string;
+  cards: CardChoice[];
+}
+
 // API Response types
 export interface ApiResponse<T> {
   message?: string;
@@ -109,4 +527,12 @@ export interface SpendingsResponse extends ApiResponse<UserSpending[]> {
 
 export interface RecommendationsResponse extends ApiResponse<Recommendation[]> {
   recommendations?: Recommendation[];
+}
+
+export interface CategoryRecommendationsResponse {
+  category: Category;
+  recommendations: Recommendation[];
+  page: number;
+  page_size: number;
+  total: number;
 } 
\ No newline at end of file